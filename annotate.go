@@ -0,0 +1,266 @@
+package weave
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// legacyTag is the struct tag key older projects used for weave options
+const legacyTag = "weaviate"
+
+// AnnotateOptions selects the rewrites of Annotate
+type AnnotateOptions struct {
+	// Descriptions pins the descriptions taken from doc comments: marked structs get a
+	// +weave:desc: marker and their fields a description tag option
+	Descriptions bool
+
+	// Normalize rewrites weave tags canonically: known options spelled and ordered
+	// consistently, bare boolean options set to true, and options of legacy weaviate
+	// tags moved into the weave tag
+	Normalize bool
+}
+
+// booleanTagOptions are the options holding true or false
+var booleanTagOptions = []string{"indexFilterable", "indexSearchable", "indexInverted", "indexRangeFilters"}
+
+// Annotate rewrites the +weave markers and weave tags of the structs in dir as selected
+// by opts, keeping the rest of the source as it is. It returns the paths of the changed
+// files, and warnings for struct tags it leaves alone.
+func Annotate(dir string, opts AnnotateOptions) ([]string, Diagnostics, error) {
+	var diags Diagnostics
+	changed, err := rewriteStructs(dir, func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec) []textEdit {
+		doc := typeDoc(decl, spec)
+		marked := hasWeaviateMarker(doc)
+
+		var edits []textEdit
+		if opts.Descriptions && marked && extractWeaviateDescription(doc) == "" {
+			if desc := commentDescription(doc); desc != "" {
+				edits = append(edits, insertAbove(fset, file, decl, spec, "// "+weaviateDescMarker+" "+desc+"\n"))
+			}
+		}
+
+		for _, field := range spec.Type.(*ast.StructType).Fields.List {
+			if len(field.Names) == 0 || !ast.IsExported(field.Names[0].Name) {
+				continue
+			}
+			if edit, ok := annotateField(fset, field, spec.Name.Name, opts, marked, &diags); ok {
+				edits = append(edits, edit)
+			}
+		}
+		return edits
+	})
+	return changed, diags, err
+}
+
+// annotateField rewrites the tag of a field, returning false when it stays as it is
+func annotateField(fset *token.FileSet, field *ast.Field, structName string, opts AnnotateOptions, marked bool, diags *Diagnostics) (textEdit, bool) {
+	var pairs []tagPair
+	if field.Tag != nil {
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil || parseJSONTag(tag).Skip {
+			return textEdit{}, false
+		}
+		var ok bool
+		if pairs, ok = parseStructTag(tag); !ok {
+			diags.add(SeverityWarning, fset.Position(field.Tag.Pos()), "malformed struct tag on field %s.%s left as it is", structName, field.Names[0].Name)
+			return textEdit{}, false
+		}
+	}
+
+	weave := tagPairIndex(pairs, weaviateTag)
+	var original string
+	var options []string
+	if weave >= 0 {
+		original = pairs[weave].value
+		options = splitTagOptions(original)
+	}
+	legacyMoved := false
+
+	if opts.Normalize {
+		if legacy := tagPairIndex(pairs, legacyTag); legacy >= 0 {
+			// Options of the weave tag win, so they come last
+			options = append(splitTagOptions(pairs[legacy].value), options...)
+			pairs = slices.Delete(pairs, legacy, legacy+1)
+			weave = tagPairIndex(pairs, weaviateTag)
+			legacyMoved = true
+		}
+		options = normalizeTagOptions(options)
+	}
+
+	markers, _ := extractWeaviatePropMarkers(field.Doc, field.Comment)
+	_, markerDescription := markers["description"]
+	if opts.Descriptions && marked && !markerDescription && !slices.ContainsFunc(options, isDescriptionOption) {
+		desc := commentDescription(field.Doc)
+		if desc == "" {
+			desc = commentDescription(field.Comment)
+		}
+		if desc != "" {
+			options = append(options, formatTagOption("description", desc))
+			if opts.Normalize {
+				options = normalizeTagOptions(options)
+			}
+		}
+	}
+
+	value := strings.Join(options, ",")
+	if value == original && !legacyMoved {
+		return textEdit{}, false
+	}
+	switch {
+	case weave >= 0:
+		pairs[weave].value = value
+	case value != "":
+		pairs = append(pairs, tagPair{weaviateTag, value})
+	}
+
+	tag := formatStructTag(pairs)
+	if field.Tag == nil {
+		end := fset.Position(field.Type.End()).Offset
+		return textEdit{end, end, " " + quoteStructTag(tag)}, true
+	}
+
+	return textEdit{fset.Position(field.Tag.Pos()).Offset, fset.Position(field.Tag.End()).Offset, quoteStructTag(tag)}, true
+}
+
+// normalizeTagOptions trims, quotes and orders weave tag options, fixes the case of
+// known options, sets bare boolean options to true and keeps the last of repeated options.
+// Options that don't parse are kept as they are.
+func normalizeTagOptions(options []string) []string {
+	byKey := make(map[string]int)
+	var normalized []string
+	for _, option := range options {
+		if key, value, ok := strings.Cut(option, "="); ok {
+			option = strings.TrimSpace(key) + "=" + strings.TrimSpace(value)
+		}
+		key, value, hasValue, err := parseTagOption(strings.TrimSpace(option))
+		if err != nil {
+			normalized = append(normalized, option)
+			continue
+		}
+		key = canonicalTagOption(key)
+		if key == "" {
+			continue
+		}
+		if !hasValue && slices.Contains(booleanTagOptions, key) {
+			value, hasValue = "true", true
+		}
+
+		option = key
+		if hasValue {
+			option = formatTagOption(key, value)
+		}
+		if i, ok := byKey[key]; ok {
+			normalized[i] = option
+			continue
+		}
+		byKey[key] = len(normalized)
+		normalized = append(normalized, option)
+	}
+
+	slices.SortStableFunc(normalized, func(a, b string) int {
+		return tagOptionRank(a) - tagOptionRank(b)
+	})
+	return normalized
+}
+
+// canonicalTagOption spells a known option as the generator reads it, e.g. indexfilterable
+// becomes indexFilterable
+func canonicalTagOption(key string) string {
+	module, setting, isModule := strings.Cut(key, ".")
+	if isModule && strings.EqualFold(module, "moduleConfig") {
+		return "moduleConfig." + setting
+	}
+	for _, known := range propertyTagKeys {
+		if strings.EqualFold(key, known) {
+			return known
+		}
+	}
+	return key
+}
+
+// tagOptionRank orders an option by propertyTagKeys, with moduleConfig.<module> options
+// after moduleConfig and unknown options last
+func tagOptionRank(option string) int {
+	key, _, _ := strings.Cut(option, "=")
+	if strings.HasPrefix(key, "moduleConfig.") {
+		key = "moduleConfig"
+	}
+	if i := slices.Index(propertyTagKeys, key); i >= 0 {
+		return i
+	}
+	return len(propertyTagKeys)
+}
+
+// isDescriptionOption reports whether a weave tag option sets the description
+func isDescriptionOption(option string) bool {
+	return strings.HasPrefix(strings.TrimSpace(option), "description=")
+}
+
+// tagPair is one key:"value" pair of a struct tag
+type tagPair struct {
+	key, value string
+}
+
+// parseStructTag splits a struct tag into its pairs the way reflect.StructTag reads
+// them, returning false when the tag is malformed
+func parseStructTag(tag string) ([]tagPair, bool) {
+	var pairs []tagPair
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, true
+		}
+
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, false
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, false
+		}
+		pairs = append(pairs, tagPair{key, value})
+		tag = tag[i+1:]
+	}
+}
+
+// formatStructTag joins pairs into a struct tag
+func formatStructTag(pairs []tagPair) string {
+	parts := make([]string, len(pairs))
+	for i, pair := range pairs {
+		parts[i] = pair.key + ":" + strconv.Quote(pair.value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteStructTag returns the Go literal of a struct tag, raw unless it holds a backquote
+func quoteStructTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// tagPairIndex returns the index of the pair with key, or -1
+func tagPairIndex(pairs []tagPair, key string) int {
+	return slices.IndexFunc(pairs, func(pair tagPair) bool { return pair.key == key })
+}
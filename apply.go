@@ -0,0 +1,373 @@
+package weave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+)
+
+// DefaultApplyConcurrency is the number of schema requests Apply sends at once
+const DefaultApplyConcurrency = 8
+
+// ApplyOptions controls how Apply creates the schema
+type ApplyOptions struct {
+	Concurrency int // classes or properties created at once
+
+	// RollbackOnError deletes the classes created so far when a request fails. Weaviate
+	// can't delete properties, so properties added to existing classes stay.
+	RollbackOnError bool
+
+	// classesOnly leaves existing classes alone, without adding their missing properties
+	classesOnly bool
+}
+
+// ApplyResult lists what Apply changed on the cluster
+type ApplyResult struct {
+	Created    []string // classes created
+	Properties []string // properties added to existing classes or deferred references, as Class.property
+	Existing   []string // classes that already existed
+
+	// After a failure: what wasn't applied, as classes and Class.property, and the
+	// classes deleted by RollbackOnError
+	Pending    []string
+	RolledBack []string
+}
+
+// Apply creates the classes of schema that are missing from the cluster, and the
+// properties missing from the classes that exist. Classes are created concurrently in
+// waves, each class once the classes it references exist. References that can't be
+// ordered, within a reference cycle or to the class itself, are added as properties
+// once every class exists. Settings of existing classes and properties are left alone;
+// compare them with weave diff.
+//
+// The result records what was applied even when Apply fails, with the rest in Pending.
+// Apply skips what exists, so running it again resumes a failed apply.
+func Apply(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, opts ApplyOptions) (ApplyResult, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultApplyConcurrency
+	}
+
+	var result ApplyResult
+	existing, err := client.schemaProperties(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	// Properties of existing classes are added after the new classes they may reference
+	var deferred []classProperty
+	pending := make(map[string]WeaviateClass)
+	for _, class := range schema.Classes {
+		props, ok := existing[class.Class]
+		if !ok {
+			pending[class.Class] = class
+			continue
+		}
+		result.Existing = append(result.Existing, class.Class)
+		if opts.classesOnly {
+			continue
+		}
+		for _, prop := range class.Properties {
+			if !props[prop.Name] {
+				deferred = append(deferred, classProperty{class.Class, prop})
+			}
+		}
+	}
+
+	for len(pending) > 0 {
+		var wave []WeaviateClass
+		for _, name := range sortedKeys(pending) {
+			if !referencesOthers(pending[name], pending) {
+				wave = append(wave, pending[name])
+			}
+		}
+		if len(wave) == 0 {
+			// The remaining classes depend on a reference cycle; break it at its first class
+			for _, name := range sortedKeys(pending) {
+				if inCycle(name, pending) {
+					wave = append(wave, pending[name])
+					break
+				}
+			}
+		}
+		for i := range wave {
+			var refs []classProperty
+			wave[i], refs = withoutReferences(wave[i], pending)
+			deferred = append(deferred, refs...)
+		}
+
+		var mu sync.Mutex
+		err := forEachConcurrently(ctx, wave, opts.Concurrency, func(ctx context.Context, class WeaviateClass) error {
+			if err := client.createClass(ctx, class); err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			result.Created = append(result.Created, class.Class)
+			delete(pending, class.Class)
+			return nil
+		})
+		if err != nil {
+			result.Pending = append(sortedKeys(pending), propertyNames(deferred)...)
+			return result, rollback(client, &result, opts, err)
+		}
+	}
+
+	added := make([]bool, len(deferred))
+	err = forEachConcurrently(ctx, indexes(deferred), opts.Concurrency, func(ctx context.Context, i int) error {
+		if err := client.addProperty(ctx, deferred[i].class, deferred[i].prop); err != nil {
+			return err
+		}
+		added[i] = true
+		return nil
+	})
+	for i, p := range deferred {
+		if added[i] {
+			result.Properties = append(result.Properties, p.class+"."+p.prop.Name)
+		} else {
+			result.Pending = append(result.Pending, p.class+"."+p.prop.Name)
+		}
+	}
+	if err != nil {
+		return result, rollback(client, &result, opts, err)
+	}
+
+	return result, nil
+}
+
+// ensureAttempts is the number of times EnsureSchema applies the schema while other
+// processes create the same classes or properties
+const ensureAttempts = 3
+
+// EnsureOptions controls EnsureSchema
+type EnsureOptions struct {
+	// AddMissingProperties adds the properties missing from classes that exist, which
+	// are otherwise left alone
+	AddMissingProperties bool
+
+	Concurrency int // classes or properties created at once
+}
+
+// EnsureSchema creates the classes of schema missing from the cluster, for applications
+// provisioning their classes on startup, e.g. from classes built with the reflect package.
+// Unlike Apply it's safe to run from several instances at once: when another instance
+// creates a class or property first, the schema is read again and the rest applied. The
+// result lists what this call changed.
+func EnsureSchema(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, opts EnsureOptions) (ApplyResult, error) {
+	applyOpts := ApplyOptions{Concurrency: opts.Concurrency, classesOnly: !opts.AddMissingProperties}
+
+	var created, properties []string
+	for attempt := 1; ; attempt++ {
+		result, err := Apply(ctx, client, schema, applyOpts)
+		result.Created = append(created, result.Created...)
+		result.Properties = append(properties, result.Properties...)
+		result.Existing = slices.DeleteFunc(result.Existing, func(name string) bool { return slices.Contains(created, name) })
+		if err == nil || attempt == ensureAttempts || !isAlreadyExists(err) {
+			return result, err
+		}
+		created, properties = result.Created, result.Properties
+	}
+}
+
+// rollback deletes the classes created by a failed apply when opts.RollbackOnError is
+// set, and returns the error the apply failed with
+func rollback(client *ClusterClient, result *ApplyResult, opts ApplyOptions, err error) error {
+	if !opts.RollbackOnError {
+		return err
+	}
+
+	// The apply's context may be what failed, so the rollback gets its own
+	ctx := context.Background()
+	for i := len(result.Created) - 1; i >= 0; i-- {
+		className := result.Created[i]
+		if rbErr := client.deleteClass(ctx, className); rbErr != nil {
+			return fmt.Errorf("%v; rollback failed: %v", err, rbErr)
+		}
+		result.RolledBack = append(result.RolledBack, className)
+	}
+	return err
+}
+
+// propertyNames lists properties as Class.property
+func propertyNames(props []classProperty) []string {
+	names := make([]string, len(props))
+	for i, p := range props {
+		names[i] = p.class + "." + p.prop.Name
+	}
+	return names
+}
+
+// indexes returns the indexes of items, for concurrent calls recording per-item results
+func indexes[T any](items []T) []int {
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+// classProperty is a property to add to an existing class
+type classProperty struct {
+	class string
+	prop  WeaviateProperty
+}
+
+// referencesOthers reports whether class references one of classes other than itself
+func referencesOthers(class WeaviateClass, classes map[string]WeaviateClass) bool {
+	return slices.ContainsFunc(class.Properties, func(prop WeaviateProperty) bool {
+		return slices.ContainsFunc(prop.DataType, func(dataType string) bool {
+			_, ok := classes[dataType]
+			return ok && dataType != class.Class
+		})
+	})
+}
+
+// inCycle reports whether the class named name reaches itself through references to classes
+func inCycle(name string, classes map[string]WeaviateClass) bool {
+	seen := make(map[string]bool)
+	next := []string{name}
+	for len(next) > 0 {
+		class := classes[next[0]]
+		next = next[1:]
+		for _, prop := range class.Properties {
+			for _, dataType := range prop.DataType {
+				if _, ok := classes[dataType]; !ok || seen[dataType] {
+					continue
+				}
+				if dataType == name {
+					return true
+				}
+				seen[dataType] = true
+				next = append(next, dataType)
+			}
+		}
+	}
+	return false
+}
+
+// referencesClass reports whether a property references one of classes
+func referencesClass(prop WeaviateProperty, classes map[string]WeaviateClass) bool {
+	return slices.ContainsFunc(prop.DataType, func(dataType string) bool {
+		_, ok := classes[dataType]
+		return ok
+	})
+}
+
+// withoutReferences splits off the properties of class referencing one of classes
+func withoutReferences(class WeaviateClass, classes map[string]WeaviateClass) (WeaviateClass, []classProperty) {
+	var refs []classProperty
+	props := make([]WeaviateProperty, 0, len(class.Properties))
+	for _, prop := range class.Properties {
+		if referencesClass(prop, classes) {
+			refs = append(refs, classProperty{class.Class, prop})
+		} else {
+			props = append(props, prop)
+		}
+	}
+	class.Properties = props
+	return class, refs
+}
+
+// sortedKeys returns the class names in order, so waves are created deterministically
+func sortedKeys(classes map[string]WeaviateClass) []string {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// forEachConcurrently calls fn for every item, at most n at once, and returns the
+// first error. No more calls start after an error, but the running ones complete, so
+// the caller knows what was applied.
+func forEachConcurrently[T any](ctx context.Context, items []T, n int, fn func(context.Context, T) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	sem := make(chan struct{}, n)
+	for _, item := range items {
+		sem <- struct{}{}
+		if failed() || ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, item); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
+
+// schemaProperties returns the property names of every class on the cluster
+func (c *ClusterClient) schemaProperties(ctx context.Context) (map[string]map[string]bool, error) {
+	var schema struct {
+		Classes []struct {
+			Class      string `json:"class"`
+			Properties []struct {
+				Name string `json:"name"`
+			} `json:"properties"`
+		} `json:"classes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/schema", nil, nil, &schema); err != nil {
+		return nil, fmt.Errorf("error reading schema: %v", err)
+	}
+
+	classes := make(map[string]map[string]bool, len(schema.Classes))
+	for _, class := range schema.Classes {
+		props := make(map[string]bool, len(class.Properties))
+		for _, prop := range class.Properties {
+			props[prop.Name] = true
+		}
+		classes[class.Class] = props
+	}
+	return classes, nil
+}
+
+// createClass creates a class
+func (c *ClusterClient) createClass(ctx context.Context, class WeaviateClass) error {
+	if err := c.do(ctx, http.MethodPost, "/schema", nil, class, nil); err != nil {
+		return fmt.Errorf("error creating class %s: %w", class.Class, err)
+	}
+	return nil
+}
+
+// addProperty adds a property to an existing class
+func (c *ClusterClient) addProperty(ctx context.Context, className string, prop WeaviateProperty) error {
+	if err := c.do(ctx, http.MethodPost, "/schema/"+url.PathEscape(className)+"/properties", nil, prop, nil); err != nil {
+		return fmt.Errorf("error adding property %s.%s: %w", className, prop.Name, err)
+	}
+	return nil
+}
+
+// deleteClass deletes a class with all its objects
+func (c *ClusterClient) deleteClass(ctx context.Context, className string) error {
+	if err := c.do(ctx, http.MethodDelete, "/schema/"+url.PathEscape(className), nil, nil, nil); err != nil {
+		return fmt.Errorf("error deleting class %s: %v", className, err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package weave
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Backfill is a data transformation of a class, declared with a +weave:backfill: marker
+// naming it on a function of the package declaring the class:
+//
+//	// +weave:backfill: recompute-slug
+//	func RecomputeSlug(ctx context.Context, a *Article) (bool, error)
+//
+// The generated code registers it in Backfills, and RunBackfills calls it with every object
+// of the class, writing back the objects it reports as changed.
+type Backfill struct {
+	Name  string // records the backfill's progress, so it mustn't change once it has run
+	Func  string
+	Class string
+	Pos   token.Position
+}
+
+// collectBackfills finds the functions of a file marked as backfills of the classes of its
+// package, reporting the ones generated code couldn't call
+func collectBackfills(file *ast.File, scope *fileScope, classes []WeaviateClass) []Backfill {
+	var backfills []Backfill
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !hasBackfillMarker(fn.Doc) {
+			continue
+		}
+
+		name := extractMarkerText(fn.Doc, weaviateBackfillMarker)
+		switch {
+		case name == "":
+			scope.errorf(fn.Pos(), "backfill %s has no name, e.g. %s recompute-slug", fn.Name.Name, weaviateBackfillMarker)
+			continue
+		case fn.Recv != nil || fn.Type.TypeParams != nil:
+			scope.errorf(fn.Pos(), "backfill %s must be a function without type parameters", fn.Name.Name)
+			continue
+		}
+
+		class, ok := backfillClass(scope, fn.Type)
+		if !ok {
+			scope.errorf(fn.Pos(), "backfill %s must be a func(context.Context, *Class) (bool, error) of a class declared in its package", fn.Name.Name)
+			continue
+		}
+		if !slices.ContainsFunc(classes, func(c WeaviateClass) bool { return c.Class == class }) {
+			scope.errorf(fn.Pos(), "backfill %s changes %s, which isn't a class declared in its package", fn.Name.Name, class)
+			continue
+		}
+
+		backfills = append(backfills, Backfill{
+			Name:  name,
+			Func:  fn.Name.Name,
+			Class: class,
+			Pos:   scope.fset.Position(fn.Pos()),
+		})
+	}
+	return backfills
+}
+
+// hasBackfillMarker checks if the comment group contains the +weave:backfill: marker
+func hasBackfillMarker(cg *ast.CommentGroup) bool {
+	return cg != nil && slices.ContainsFunc(cg.List, func(c *ast.Comment) bool {
+		return strings.Contains(c.Text, weaviateBackfillMarker)
+	})
+}
+
+// backfillClass returns the class of a backfill's signature,
+// func(context.Context, *Class) (bool, error)
+func backfillClass(scope *fileScope, fn *ast.FuncType) (string, bool) {
+	params := fieldTypes(fn.Params)
+	results := fieldTypes(fn.Results)
+	if len(params) != 2 || len(results) != 2 {
+		return "", false
+	}
+
+	ctx, ok := params[0].(*ast.SelectorExpr)
+	if !ok || ctx.Sel.Name != "Context" {
+		return "", false
+	}
+	if pkg, ok := ctx.X.(*ast.Ident); !ok || scope.imports[pkg.Name] != "context" {
+		return "", false
+	}
+
+	ptr, ok := params[1].(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	class, ok := ptr.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+
+	for i, want := range []string{"bool", "error"} {
+		if ident, ok := results[i].(*ast.Ident); !ok || ident.Name != want {
+			return "", false
+		}
+	}
+	return class.Name, true
+}
+
+// fieldTypes lists the type of every parameter or result of a field list, once per name
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+// checkBackfills reports backfills sharing a name, whose progress would be mixed up, and
+// backfills of read-only classes, and drops the backfills of classes dropped as duplicates
+func checkBackfills(backfills []Backfill, classes []WeaviateClass, diags *Diagnostics) []Backfill {
+	seen := make(map[string]Backfill)
+	var kept []Backfill
+	for _, backfill := range backfills {
+		i := slices.IndexFunc(classes, func(c WeaviateClass) bool {
+			return c.Class == backfill.Class && filepath.Dir(c.Pos.Filename) == filepath.Dir(backfill.Pos.Filename)
+		})
+		if i < 0 {
+			continue
+		}
+		if classes[i].ReadOnly {
+			diags.add(SeverityError, backfill.Pos, "backfill %s changes %s, which is read-only", backfill.Func, backfill.Class)
+			continue
+		}
+		if other, ok := seen[backfill.Name]; ok {
+			diags.add(SeverityError, backfill.Pos, "backfills %s and %s are both named %s", other.Func, backfill.Func, backfill.Name)
+			continue
+		}
+		seen[backfill.Name] = backfill
+		kept = append(kept, backfill)
+	}
+	return kept
+}
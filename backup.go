@@ -0,0 +1,142 @@
+package weave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BackupBackends are the storage backends of Weaviate's backup modules, enabled on the
+// cluster with backup-filesystem, backup-s3, backup-gcs and backup-azure
+var BackupBackends = []string{"filesystem", "s3", "gcs", "azure"}
+
+// DefaultBackupPollInterval is how often a waiting backup or restore checks its status
+const DefaultBackupPollInterval = 2 * time.Second
+
+// Backup statuses reported by Weaviate
+const (
+	BackupStarted      = "STARTED"
+	BackupTransferring = "TRANSFERRING"
+	BackupTransferred  = "TRANSFERRED"
+	BackupSuccess      = "SUCCESS"
+	BackupFailed       = "FAILED"
+)
+
+// BackupOptions controls CreateBackup and RestoreBackup
+type BackupOptions struct {
+	// Wait polls the status until the backup or restore succeeds or fails, and returns an
+	// error when it fails; otherwise they return once Weaviate started it
+	Wait bool
+
+	PollInterval time.Duration // between status checks while waiting
+}
+
+// BackupStatus is the state of a backup or restore on the cluster
+type BackupStatus struct {
+	ID      string   `json:"id"`
+	Backend string   `json:"backend"`
+	Path    string   `json:"path,omitempty"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+	Classes []string `json:"classes,omitempty"`
+}
+
+// CreateBackup starts a Weaviate backup of the schema's classes to backend, stored as id
+func CreateBackup(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, backend, id string, opts BackupOptions) (BackupStatus, error) {
+	if err := checkBackupBackend(backend); err != nil {
+		return BackupStatus{}, err
+	}
+
+	var status BackupStatus
+	body := map[string]interface{}{"id": id, "include": schemaClassNames(schema)}
+	if err := client.do(ctx, http.MethodPost, "/backups/"+url.PathEscape(backend), nil, body, &status); err != nil {
+		return status, fmt.Errorf("error creating backup %s: %v", id, err)
+	}
+	if !opts.Wait {
+		return status, nil
+	}
+	return waitForBackup(ctx, client, backend, id, false, opts.PollInterval)
+}
+
+// RestoreBackup starts restoring the schema's classes from the backup id on backend. The
+// classes mustn't exist on the cluster.
+func RestoreBackup(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, backend, id string, opts BackupOptions) (BackupStatus, error) {
+	if err := checkBackupBackend(backend); err != nil {
+		return BackupStatus{}, err
+	}
+
+	var status BackupStatus
+	body := map[string]interface{}{"include": schemaClassNames(schema)}
+	if err := client.do(ctx, http.MethodPost, "/backups/"+url.PathEscape(backend)+"/"+url.PathEscape(id)+"/restore", nil, body, &status); err != nil {
+		return status, fmt.Errorf("error restoring backup %s: %v", id, err)
+	}
+	if !opts.Wait {
+		return status, nil
+	}
+	return waitForBackup(ctx, client, backend, id, true, opts.PollInterval)
+}
+
+// GetBackupStatus reads the status of the backup id on backend, or of its restore
+func GetBackupStatus(ctx context.Context, client *ClusterClient, backend, id string, restore bool) (BackupStatus, error) {
+	if err := checkBackupBackend(backend); err != nil {
+		return BackupStatus{}, err
+	}
+
+	path := "/backups/" + url.PathEscape(backend) + "/" + url.PathEscape(id)
+	if restore {
+		path += "/restore"
+	}
+	var status BackupStatus
+	if err := client.do(ctx, http.MethodGet, path, nil, nil, &status); err != nil {
+		return status, fmt.Errorf("error reading status of backup %s: %v", id, err)
+	}
+	return status, nil
+}
+
+// waitForBackup polls the status of a backup or restore until it succeeds or fails
+func waitForBackup(ctx context.Context, client *ClusterClient, backend, id string, restore bool, interval time.Duration) (BackupStatus, error) {
+	if interval <= 0 {
+		interval = DefaultBackupPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status, err := GetBackupStatus(ctx, client, backend, id, restore)
+		switch {
+		case err != nil:
+			return status, err
+		case status.Status == BackupSuccess:
+			return status, nil
+		case status.Status == BackupFailed:
+			return status, fmt.Errorf("error in backup %s: %s", id, status.Error)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+}
+
+// checkBackupBackend checks backend names a backup module
+func checkBackupBackend(backend string) error {
+	if !slices.Contains(BackupBackends, backend) {
+		return fmt.Errorf("unknown backup backend %q (expected one of %s)", backend, strings.Join(BackupBackends, ", "))
+	}
+	return nil
+}
+
+// schemaClassNames lists the names of the schema's classes
+func schemaClassNames(schema *WeaviateSchemaDefinition) []string {
+	names := make([]string, len(schema.Classes))
+	for i, class := range schema.Classes {
+		names[i] = class.Class
+	}
+	return names
+}
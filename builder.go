@@ -0,0 +1,437 @@
+package weave
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// classNamePattern is the pattern Weaviate requires class names to match
+var classNamePattern = regexp.MustCompile(`^[A-Z][_0-9A-Za-z]{0,254}$`)
+
+// builderGoTypes are the Go types of the fields the generated code expects for properties
+// added with a ClassBuilder, by data type
+var builderGoTypes = map[string]string{
+	"text":      "string",
+	"text[]":    "[]string",
+	"int":       "int",
+	"int[]":     "[]int",
+	"number":    "float64",
+	"number[]":  "[]float64",
+	"boolean":   "bool",
+	"boolean[]": "[]bool",
+	"date":      "time.Time",
+	"date[]":    "[]time.Time",
+	"uuid":      "uuid.UUID",
+	"uuid[]":    "[]uuid.UUID",
+	"blob":      "[]byte",
+}
+
+// ClassBuilder assembles a class in code, e.g. one per tenant, for the same JSON, apply
+// and codegen backends as classes parsed from Go sources:
+//
+//	class, err := weave.NewClass("Article").
+//		Text("title", weave.WithTokenization("word")).
+//		Int("views", weave.WithRangeFilters()).
+//		Reference("author", "Author").
+//		Build()
+//
+// The first invalid call is returned by Build; the calls after it are ignored.
+type ClassBuilder struct {
+	class WeaviateClass
+	err   error
+}
+
+// NewClass starts a class named name, with the defaults of classes parsed from sources
+func NewClass(name string) *ClassBuilder {
+	b := &ClassBuilder{class: WeaviateClass{
+		Class:             name,
+		Properties:        []WeaviateProperty{},
+		VectorIndexType:   "hnsw",
+		Vectorizer:        "text2vec-contextionary",
+		defaultVectorizer: true,
+	}}
+	if !classNamePattern.MatchString(name) {
+		b.err = fmt.Errorf("class name %q doesn't match Weaviate's class name pattern %s", name, classNamePattern)
+	}
+	return b
+}
+
+// Description describes the class
+func (b *ClassBuilder) Description(description string) *ClassBuilder {
+	b.class.Description = description
+	return b
+}
+
+// Package names the Go package the generated code for the class goes to
+func (b *ClassBuilder) Package(name string) *ClassBuilder {
+	b.class.Package = name
+	return b
+}
+
+// Owner names the team owning the class, like +weave:owner:
+func (b *ClassBuilder) Owner(owner string) *ClassBuilder {
+	b.class.Owner = owner
+	return b
+}
+
+// Vectorizer sets the vectorizer module of the class, e.g. text2vec-openai or none
+func (b *ClassBuilder) Vectorizer(module string) *ClassBuilder {
+	b.class.Vectorizer = module
+	b.class.defaultVectorizer = false
+	return b
+}
+
+// VectorIndex sets the vector index type of the class and its config, which may be nil
+func (b *ClassBuilder) VectorIndex(indexType string, config map[string]interface{}) *ClassBuilder {
+	b.class.VectorIndexType = indexType
+	b.class.VectorIndexConfig = copyConfig(config)
+	return b
+}
+
+// ModuleConfig sets the class's settings for a module, e.g. the model of its vectorizer
+func (b *ClassBuilder) ModuleConfig(module string, config map[string]interface{}) *ClassBuilder {
+	if b.class.ModuleConfig == nil {
+		b.class.ModuleConfig = make(map[string]interface{})
+	}
+	b.class.ModuleConfig[module] = copyConfig(config)
+	return b
+}
+
+// MultiTenancy enables multi-tenancy for the class
+func (b *ClassBuilder) MultiTenancy() *ClassBuilder {
+	if b.class.MultiTenancyConfig == nil {
+		b.class.MultiTenancyConfig = make(map[string]interface{})
+	}
+	b.class.MultiTenancyConfig["enabled"] = true
+	return b
+}
+
+// AutoTenantCreation enables multi-tenancy, with tenants created on their first write
+func (b *ClassBuilder) AutoTenantCreation() *ClassBuilder {
+	b.MultiTenancy().class.MultiTenancyConfig["autoTenantCreation"] = true
+	return b
+}
+
+// AutoTenantActivation enables multi-tenancy, with inactive tenants activated on access
+func (b *ClassBuilder) AutoTenantActivation() *ClassBuilder {
+	b.MultiTenancy().class.MultiTenancyConfig["autoTenantActivation"] = true
+	return b
+}
+
+// Replication sets the replication factor of the class
+func (b *ClassBuilder) Replication(factor int) *ClassBuilder {
+	if factor < 1 {
+		return b.fail(fmt.Errorf("replication factor of class %s is %d, it must be at least 1", b.class.Class, factor))
+	}
+	b.class.ReplicationConfig = map[string]interface{}{"factor": factor}
+	return b
+}
+
+// InvertedIndex sets keys of the inverted index config of the class, e.g. bm25
+func (b *ClassBuilder) InvertedIndex(config map[string]interface{}) *ClassBuilder {
+	if b.class.InvertedIndexConfig == nil {
+		b.class.InvertedIndexConfig = make(map[string]interface{})
+	}
+	for key, value := range config {
+		b.class.InvertedIndexConfig[key] = copyConfigValue(value)
+	}
+	return b
+}
+
+// IndexTimestamps indexes the creation and update times of objects, for timestamp filters
+func (b *ClassBuilder) IndexTimestamps() *ClassBuilder {
+	return b.InvertedIndex(map[string]interface{}{"indexTimestamps": true})
+}
+
+// IndexNullState indexes whether properties are null, for FilterIsNull
+func (b *ClassBuilder) IndexNullState() *ClassBuilder {
+	return b.InvertedIndex(map[string]interface{}{"indexNullState": true})
+}
+
+// IndexPropertyLength indexes the length of properties, for filters on it
+func (b *ClassBuilder) IndexPropertyLength() *ClassBuilder {
+	return b.InvertedIndex(map[string]interface{}{"indexPropertyLength": true})
+}
+
+// Text adds a text property
+func (b *ClassBuilder) Text(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "text", opts...)
+}
+
+// TextArray adds a text[] property
+func (b *ClassBuilder) TextArray(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "text[]", opts...)
+}
+
+// Int adds an int property
+func (b *ClassBuilder) Int(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "int", opts...)
+}
+
+// IntArray adds an int[] property
+func (b *ClassBuilder) IntArray(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "int[]", opts...)
+}
+
+// Number adds a number property
+func (b *ClassBuilder) Number(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "number", opts...)
+}
+
+// NumberArray adds a number[] property
+func (b *ClassBuilder) NumberArray(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "number[]", opts...)
+}
+
+// Boolean adds a boolean property
+func (b *ClassBuilder) Boolean(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "boolean", opts...)
+}
+
+// Date adds a date property
+func (b *ClassBuilder) Date(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "date", opts...)
+}
+
+// UUID adds a uuid property
+func (b *ClassBuilder) UUID(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "uuid", opts...)
+}
+
+// Blob adds a blob property
+func (b *ClassBuilder) Blob(name string, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "blob", opts...)
+}
+
+// Object adds an object property with nested properties, built with NewProperty
+func (b *ClassBuilder) Object(name string, nested []WeaviateProperty, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "object", append([]PropertyOption{withNested(nested)}, opts...)...)
+}
+
+// ObjectArray adds an object[] property with nested properties, built with NewProperty
+func (b *ClassBuilder) ObjectArray(name string, nested []WeaviateProperty, opts ...PropertyOption) *ClassBuilder {
+	return b.Property(name, "object[]", append([]PropertyOption{withNested(nested)}, opts...)...)
+}
+
+// Reference adds a cross-reference to the target class
+func (b *ClassBuilder) Reference(name, target string, opts ...PropertyOption) *ClassBuilder {
+	if !classNamePattern.MatchString(target) {
+		return b.fail(fmt.Errorf("property %s.%s references %q, which isn't a class name", b.class.Class, name, target))
+	}
+	return b.Property(name, target, append([]PropertyOption{WithGoType("[]" + target)}, opts...)...)
+}
+
+// Property adds a property of any data type, e.g. geoCoordinates
+func (b *ClassBuilder) Property(name, dataType string, opts ...PropertyOption) *ClassBuilder {
+	if b.err != nil {
+		return b
+	}
+	prop, err := newProperty(name, dataType, opts)
+	if err != nil {
+		return b.fail(fmt.Errorf("property %s.%s: %v", b.class.Class, name, err))
+	}
+	for _, other := range b.class.Properties {
+		if strings.EqualFold(other.Name, name) {
+			return b.fail(fmt.Errorf("property %s.%s is added twice", b.class.Class, name))
+		}
+	}
+	if prop.Version {
+		for _, other := range b.class.Properties {
+			if other.Version {
+				return b.fail(fmt.Errorf("property %s.%s is the version, but %s already is", b.class.Class, name, other.Name))
+			}
+		}
+	}
+	b.class.Properties = append(b.class.Properties, prop)
+	return b
+}
+
+// fail records the first error for Build
+func (b *ClassBuilder) fail(err error) *ClassBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the class, or the first error of the calls building it
+func (b *ClassBuilder) Build() (*WeaviateClass, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	class := b.class
+	class.Properties = slices.Clone(b.class.Properties)
+	return &class, nil
+}
+
+// NewSchema builds the classes into a schema, checking that their names are unique
+func NewSchema(classes ...*ClassBuilder) (*WeaviateSchemaDefinition, error) {
+	schema := &WeaviateSchemaDefinition{Classes: []WeaviateClass{}}
+	seen := make(map[string]bool, len(classes))
+	for _, b := range classes {
+		class, err := b.Build()
+		if err != nil {
+			return nil, err
+		}
+		if seen[strings.ToLower(class.Class)] {
+			return nil, fmt.Errorf("class %s is defined twice", class.Class)
+		}
+		seen[strings.ToLower(class.Class)] = true
+		schema.Classes = append(schema.Classes, *class)
+	}
+	return schema, nil
+}
+
+// PropertyOption configures a property added with a ClassBuilder or NewProperty
+type PropertyOption func(*WeaviateProperty) error
+
+// WithDescription describes the property
+func WithDescription(description string) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.Description = description
+		return nil
+	}
+}
+
+// WithTokenization sets the tokenization of a text or text[] property
+func WithTokenization(tokenization string) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		if err := validateTokenization(tokenization, prop.DataType); err != nil {
+			return err
+		}
+		prop.Tokenization = tokenization
+		return nil
+	}
+}
+
+// WithFilterable sets whether the property gets a filterable index
+func WithFilterable(filterable bool) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.IndexFilterable = &filterable
+		return nil
+	}
+}
+
+// WithSearchable sets whether the property gets a searchable index
+func WithSearchable(searchable bool) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.IndexSearchable = &searchable
+		return nil
+	}
+}
+
+// WithRangeFilters adds a range index to an int, number or date property
+func WithRangeFilters() PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		if !slices.Contains(rangeFilterTypes, strings.Join(prop.DataType, ",")) {
+			return fmt.Errorf("indexRangeFilters only applies to int, number or date properties, not %s", strings.Join(prop.DataType, ","))
+		}
+		prop.IndexRangeFilters = true
+		return nil
+	}
+}
+
+// WithModuleConfig sets a setting of the property for a module, e.g. whether
+// text2vec-openai skips it
+func WithModuleConfig(module, setting string, value interface{}) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		if prop.ModuleConfig == nil {
+			prop.ModuleConfig = make(map[string]interface{})
+		}
+		settings, _ := prop.ModuleConfig[module].(map[string]interface{})
+		if settings == nil {
+			settings = make(map[string]interface{})
+			prop.ModuleConfig[module] = settings
+		}
+		settings[setting] = value
+		return nil
+	}
+}
+
+// WithGoField names the field of the class's Go struct holding the property, which the
+// generated code reads and writes; it defaults to the capitalized property name
+func WithGoField(name string) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.GoField = name
+		return nil
+	}
+}
+
+// WithGoType sets the Go type of the property's field, for data types whose default
+// doesn't fit, e.g. *string or a struct for an object
+func WithGoType(goType string) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.GoType = goType
+		return nil
+	}
+}
+
+// WithVersion makes an int property the version the generated Update checks
+func WithVersion() PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		if strings.Join(prop.DataType, ",") != "int" {
+			return fmt.Errorf("version only applies to int properties, not %s", strings.Join(prop.DataType, ","))
+		}
+		prop.Version = true
+		return nil
+	}
+}
+
+// WithDeprecated deprecates the property, with a note saying e.g. what replaces it
+func WithDeprecated(note string) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		if prop.Version {
+			return fmt.Errorf("the version property can't be deprecated, as Update writes it")
+		}
+		prop.Deprecated = true
+		prop.DeprecationNote = note
+		return nil
+	}
+}
+
+// withNested sets the nested properties of an object property
+func withNested(nested []WeaviateProperty) PropertyOption {
+	return func(prop *WeaviateProperty) error {
+		prop.NestedProperties = slices.Clone(nested)
+		return nil
+	}
+}
+
+// NewProperty builds a nested property for ClassBuilder.Object and ObjectArray
+func NewProperty(name, dataType string, opts ...PropertyOption) (WeaviateProperty, error) {
+	prop, err := newProperty(name, dataType, opts)
+	if err != nil {
+		return WeaviateProperty{}, fmt.Errorf("property %s: %v", name, err)
+	}
+	return prop, nil
+}
+
+// newProperty builds a property with its Go field defaulted from its name and data type
+func newProperty(name, dataType string, opts []PropertyOption) (WeaviateProperty, error) {
+	if !propertyNamePattern.MatchString(name) {
+		return WeaviateProperty{}, fmt.Errorf("name doesn't match Weaviate's property name pattern %s", propertyNamePattern)
+	}
+	if dataType == "" {
+		return WeaviateProperty{}, fmt.Errorf("no data type")
+	}
+	prop := WeaviateProperty{
+		Name:     name,
+		DataType: []string{dataType},
+		GoField:  capitalize(name),
+		GoType:   builderGoTypes[dataType],
+	}
+	for _, opt := range opts {
+		if err := opt(&prop); err != nil {
+			return WeaviateProperty{}, err
+		}
+	}
+	if (dataType == "object" || dataType == "object[]") && len(prop.NestedProperties) == 0 {
+		return WeaviateProperty{}, fmt.Errorf("%s needs nested properties, which Weaviate can't infer", dataType)
+	}
+	if prop.GoType == "" {
+		prop.GoType = prop.GoField
+	}
+	return prop, nil
+}
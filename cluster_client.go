@@ -0,0 +1,108 @@
+package weave
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ClusterClient talks to the REST API of a Weaviate cluster for the CLI's cluster
+// commands. It uses net/http directly, so the library doesn't depend on the Weaviate
+// client the generated code uses.
+type ClusterClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// NewClusterClient creates a client for the cluster at host, e.g. "localhost:8080".
+// apiKey is sent as a bearer token when set.
+func NewClusterClient(host, scheme, apiKey string) *ClusterClient {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return &ClusterClient{
+		baseURL: scheme + "://" + strings.TrimSuffix(host, "/"),
+		apiKey:  apiKey,
+		http:    http.DefaultClient,
+	}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out
+func (c *ClusterClient) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := c.baseURL + "/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshaling request: %v", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response of %s %s: %v", method, path, err)
+	}
+	if resp.StatusCode >= 300 {
+		return &statusError{fmt.Sprintf("%s %s", method, path), resp.StatusCode, strings.TrimSpace(string(data))}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("error decoding response of %s %s: %v", method, path, err)
+		}
+	}
+	return nil
+}
+
+// statusError is returned for responses with an error status
+type statusError struct {
+	request string
+	status  int
+	body    string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.request, e.status, e.body)
+}
+
+// isAlreadyExists reports whether err is Weaviate refusing to create a class or property
+// that exists
+func isAlreadyExists(err error) bool {
+	var status *statusError
+	return errors.As(err, &status) && status.status == http.StatusUnprocessableEntity && strings.Contains(status.body, "already exists")
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	var status *statusError
+	return errors.As(err, &status) && status.status == http.StatusNotFound
+}
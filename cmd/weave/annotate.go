@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func annotateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "annotate",
+		Usage:     "Insert or normalize +weave markers and weave tags in the source files",
+		ArgsUsage: "<source directory>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "descriptions",
+				Usage: "Pin doc comments as +weave:desc: markers and description tag options",
+			},
+			&cli.BoolFlag{
+				Name:  "normalize",
+				Usage: "Rewrite weave tags canonically and move legacy weaviate tags into them",
+			},
+		},
+		Action: annotateSources,
+	}
+}
+
+func annotateSources(ctx context.Context, c *cli.Command) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	opts := weave.AnnotateOptions{
+		Descriptions: c.Bool("descriptions"),
+		Normalize:    c.Bool("normalize"),
+	}
+	if !opts.Descriptions && !opts.Normalize {
+		return fmt.Errorf("nothing to do; pass --descriptions, --normalize or both")
+	}
+
+	for _, dir := range srcs {
+		changed, diags, err := weave.Annotate(dir, opts)
+		printDiagnostics(diags)
+		if err != nil {
+			return err
+		}
+		for _, path := range changed {
+			logf("Annotated %s", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func applyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "apply",
+		Usage:     "Create the generated schema's missing classes and properties on one or more clusters",
+		ArgsUsage: "<source directory or dir/...>...",
+		Flags: append(targetFlags(),
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "Classes or properties created at once",
+				Value: weave.DefaultApplyConcurrency,
+			},
+			&cli.StringFlag{
+				Name:  "state",
+				Usage: "Save the applied schema to this state backend, e.g. s3://bucket/weave.json; overrides state in the config file",
+			},
+			&cli.BoolFlag{
+				Name:  "rollback-on-error",
+				Usage: "Delete the classes created so far when the apply fails",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-preflight",
+				Usage: "Apply without first checking the cluster's modules, version and nodes",
+			},
+			&cli.StringSliceFlag{
+				Name:  "class",
+				Usage: "Only apply these classes, e.g. --class Article,Author",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-class",
+				Usage: "Leave these classes out",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+		),
+		Action: applySchema,
+	}
+}
+
+func applySchema(ctx context.Context, c *cli.Command) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return err
+	}
+
+	targets, err := clusterTargets(c, cfg)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("--host, --cluster or --all-clusters is required")
+	}
+
+	// Every cluster is applied even when one fails, so the others don't fall behind
+	var failed []string
+	for _, target := range targets {
+		if len(targets) > 1 {
+			logf("== %s", target.name)
+		}
+		if err := applyToCluster(ctx, c, target.client, schema); err != nil {
+			if len(targets) == 1 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%s: %v\n", target.name, err)
+			failed = append(failed, target.name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("apply failed on %d of %d clusters: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+
+	location := c.String("state")
+	if location == "" {
+		location = cfg.State
+	}
+	if location == "" {
+		return nil
+	}
+	state, err := weave.OpenState(location)
+	if err != nil {
+		return err
+	}
+	schemaJSON, err := schema.ToJSON(true)
+	if err != nil {
+		return fmt.Errorf("error marshaling schema: %v", err)
+	}
+	if err := state.Save(ctx, schemaJSON); err != nil {
+		return err
+	}
+	logf("Saved the applied schema to %s", location)
+	return nil
+}
+
+// applyToCluster checks and applies the schema to one cluster, printing what it did
+func applyToCluster(ctx context.Context, c *cli.Command, client *weave.ClusterClient, schema *weave.WeaviateSchemaDefinition) error {
+	if !c.Bool("skip-preflight") {
+		diags, err := weave.Preflight(ctx, client, schema)
+		if err != nil {
+			return err
+		}
+		printDiagnostics(diags)
+		if diags.HasErrors() {
+			return fmt.Errorf("preflight failed, see the errors above; nothing was applied")
+		}
+	}
+
+	result, err := weave.Apply(ctx, client, schema, weave.ApplyOptions{
+		Concurrency:     int(c.Int("concurrency")),
+		RollbackOnError: c.Bool("rollback-on-error"),
+	})
+	for _, class := range result.Created {
+		logf("Created class %s", class)
+	}
+	for _, prop := range result.Properties {
+		logf("Added property %s", prop)
+	}
+	if err != nil {
+		for _, class := range result.RolledBack {
+			fmt.Fprintf(os.Stderr, "Rolled back class %s\n", class)
+		}
+		if len(result.Pending) > 0 {
+			fmt.Fprintln(os.Stderr, "Not applied, run weave apply again to resume:")
+			for _, name := range result.Pending {
+				fmt.Fprintf(os.Stderr, "  %s\n", name)
+			}
+		}
+		return err
+	}
+
+	if len(result.Existing) > 0 {
+		logf("%d classes already existed", len(result.Existing))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+// backupFlags are shared by the backup subcommands
+func backupFlags() []cli.Flag {
+	return append(clusterFlags(),
+		&cli.StringFlag{
+			Name:     "backend",
+			Usage:    "Backup backend enabled on the cluster: " + strings.Join(weave.BackupBackends, ", "),
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "id",
+			Usage:    "Backup ID",
+			Required: true,
+		},
+	)
+}
+
+func backupCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backup",
+		Usage: "Back up and restore the generated schema's classes with Weaviate's backup modules",
+		Commands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "Start a backup of the generated schema's classes",
+				ArgsUsage: "<source directory or dir/...>...",
+				Flags: append(backupFlags(),
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "Wait until the backup succeeds or fails",
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only back up these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+				),
+				Action: createBackup,
+			},
+			{
+				Name:      "restore",
+				Usage:     "Start restoring the generated schema's classes from a backup; the classes mustn't exist",
+				ArgsUsage: "<source directory or dir/...>...",
+				Flags: append(backupFlags(),
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "Wait until the restore succeeds or fails",
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only restore these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+				),
+				Action: restoreBackup,
+			},
+			{
+				Name:  "status",
+				Usage: "Show the status of a backup, or of its restore",
+				Flags: append(backupFlags(),
+					&cli.BoolFlag{
+						Name:  "restore",
+						Usage: "Show the status of the restore instead of the backup",
+					},
+				),
+				Action: backupStatus,
+			},
+		},
+	}
+}
+
+func createBackup(ctx context.Context, c *cli.Command) error {
+	schema, err := backupSchema(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := weave.CreateBackup(ctx, newClusterClient(c), schema, c.String("backend"), c.String("id"), weave.BackupOptions{
+		Wait: c.Bool("wait"),
+	})
+	if err != nil {
+		return err
+	}
+	printBackupStatus(status)
+	return nil
+}
+
+func restoreBackup(ctx context.Context, c *cli.Command) error {
+	schema, err := backupSchema(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := weave.RestoreBackup(ctx, newClusterClient(c), schema, c.String("backend"), c.String("id"), weave.BackupOptions{
+		Wait: c.Bool("wait"),
+	})
+	if err != nil {
+		return err
+	}
+	printBackupStatus(status)
+	return nil
+}
+
+func backupStatus(ctx context.Context, c *cli.Command) error {
+	status, err := weave.GetBackupStatus(ctx, newClusterClient(c), c.String("backend"), c.String("id"), c.Bool("restore"))
+	if err != nil {
+		return err
+	}
+	printBackupStatus(status)
+	return nil
+}
+
+// backupSchema builds the schema whose classes a backup or restore covers
+func backupSchema(c *cli.Command) (*weave.WeaviateSchemaDefinition, error) {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// printBackupStatus reports the state of a backup or restore
+func printBackupStatus(status weave.BackupStatus) {
+	fmt.Printf("Backup %s on %s: %s\n", status.ID, status.Backend, status.Status)
+	if status.Path != "" {
+		fmt.Printf("  path: %s\n", status.Path)
+	}
+	if len(status.Classes) > 0 {
+		fmt.Printf("  classes: %s\n", strings.Join(status.Classes, ", "))
+	}
+	if status.Error != "" {
+		fmt.Printf("  error: %s\n", status.Error)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+// targetFlags are shared by the commands running against several clusters at once
+func targetFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "host",
+			Usage: "Weaviate host, e.g. localhost:8080; repeat it to run against several clusters",
+		},
+		&cli.StringFlag{
+			Name:  "scheme",
+			Usage: "Weaviate URL scheme of the --host clusters",
+			Value: "http",
+		},
+		&cli.StringFlag{
+			Name:    "api-key",
+			Usage:   "Weaviate API key of the --host clusters",
+			Sources: cli.EnvVars("WEAVIATE_API_KEY"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "cluster",
+			Usage: "Run against these clusters of the config file's clusters, e.g. --cluster eu,us",
+		},
+		&cli.BoolFlag{
+			Name:  "all-clusters",
+			Usage: "Run against every cluster of the config file's clusters",
+		},
+	}
+}
+
+// clusterTarget is a cluster a command runs against
+type clusterTarget struct {
+	name   string
+	client *weave.ClusterClient
+}
+
+// clusterTargets returns the clusters named by --host, --cluster or --all-clusters, or
+// none when no cluster was named
+func clusterTargets(c *cli.Command, cfg *weave.Config) ([]clusterTarget, error) {
+	var targets []clusterTarget
+	for _, host := range c.StringSlice("host") {
+		targets = append(targets, clusterTarget{
+			name:   host,
+			client: weave.NewClusterClient(host, c.String("scheme"), c.String("api-key")),
+		})
+	}
+
+	clusters := cfg.Clusters
+	if !c.Bool("all-clusters") {
+		clusters = nil
+		for _, name := range c.StringSlice("cluster") {
+			cluster, err := cfg.Cluster(name)
+			if err != nil {
+				return nil, err
+			}
+			clusters = append(clusters, cluster)
+		}
+	} else if len(clusters) == 0 {
+		return nil, fmt.Errorf("--all-clusters needs clusters in the config file")
+	}
+	for _, cluster := range clusters {
+		apiKey := ""
+		if cluster.APIKeyEnv != "" {
+			apiKey = os.Getenv(cluster.APIKeyEnv)
+		}
+		targets = append(targets, clusterTarget{
+			name:   cluster.Name,
+			client: weave.NewClusterClient(cluster.Host, cluster.Scheme, apiKey),
+		})
+	}
+	return targets, nil
+}
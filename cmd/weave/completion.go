@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+)
+
+// The bash and zsh scripts ask weave itself for the candidates with
+// --generate-shell-completion, so they follow the command tree as it grows
+//
+//go:embed completion
+var completionScripts embed.FS
+
+func completionCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "completion",
+		Usage:     "Print the shell completion script for bash, zsh or fish, e.g. source <(weave completion bash)",
+		ArgsUsage: "<bash | zsh | fish>",
+		Action:    printCompletion,
+	}
+}
+
+func printCompletion(ctx context.Context, c *cli.Command) error {
+	var script string
+	switch shell := c.Args().First(); shell {
+	case "bash", "zsh":
+		data, err := completionScripts.ReadFile("completion/weave." + shell)
+		if err != nil {
+			return fmt.Errorf("error reading %s completion script: %v", shell, err)
+		}
+		script = string(data)
+	case "fish":
+		var err error
+		if script, err = c.Root().ToFishCompletion(); err != nil {
+			return fmt.Errorf("error generating fish completion script: %v", err)
+		}
+	case "":
+		return fmt.Errorf("shell is required: bash, zsh or fish")
+	default:
+		return fmt.Errorf("unknown shell %q (expected bash, zsh or fish)", shell)
+	}
+
+	_, err := fmt.Fprint(os.Stdout, script)
+	return err
+}
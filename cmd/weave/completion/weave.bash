@@ -0,0 +1,25 @@
+# bash completion for weave; load it with: source <(weave completion bash)
+
+_weave_completion() {
+  local cur words cword
+  COMPREPLY=()
+  if declare -F _init_completion >/dev/null 2>&1; then
+    _init_completion -n "=:" || return
+  else
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    words=("${COMP_WORDS[@]}")
+    cword=$COMP_CWORD
+  fi
+  words=("${words[@]:0:$cword}")
+  local request
+  if [[ "$cur" == "-"* ]]; then
+    request="${words[*]} ${cur} --generate-shell-completion"
+  else
+    request="${words[*]} --generate-shell-completion"
+  fi
+  local opts
+  opts=$(eval "${request}" 2>/dev/null)
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+}
+
+complete -o bashdefault -o default -o nospace -F _weave_completion weave
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Compare two schemas without a cluster, e.g. committed snapshots in CI, or a schema with the clusters named by --host, --cluster or --all-clusters",
+		ArgsUsage: "[from: schema.json | source directory | -] <to: schema.json | source directory | ->",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text or json",
+				Value:   "text",
+			},
+			&cli.BoolFlag{
+				Name:  "exit-code",
+				Usage: "Exit with status 1 when the schemas differ",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file, used when comparing a source directory",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.StringFlag{
+				Name:  "state",
+				Usage: "Compare against the schema last applied to this state backend, e.g. s3://bucket/weave.json, when only one schema is given",
+			},
+			&cli.StringFlag{
+				Name:  "owner",
+				Usage: "Only list changes to classes owned by this team, see +weave:owner:",
+			},
+			outputFormatFlag(),
+		}, targetFlags()...),
+		Action: withResult(diffSchemas),
+	}
+}
+
+func diffSchemas(ctx context.Context, c *cli.Command, r *result) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (expected text or json)", format)
+	}
+	if format == "json" && r != nil {
+		return fmt.Errorf("--format json and --output-format json can't be combined")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	targets, err := clusterTargets(c, cfg)
+	if err != nil {
+		return err
+	}
+	if len(targets) > 0 {
+		return diffClusters(ctx, c, r, targets, format)
+	}
+
+	if c.Args().Len() != 2 && (c.Args().Len() != 1 || c.String("state") == "") {
+		return fmt.Errorf("two schemas to compare, or --state and one schema, are required")
+	}
+
+	var from []byte
+	args := c.Args().Slice()
+	if len(args) == 1 {
+		state, err := weave.OpenState(c.String("state"))
+		if err != nil {
+			return err
+		}
+		if from, err = state.Load(ctx); err != nil {
+			return err
+		}
+		if from == nil {
+			return fmt.Errorf("no schema saved in %s; run weave apply --state first", c.String("state"))
+		}
+		if from, _, err = normalizeSchemaJSON(c, from, c.String("state")); err != nil {
+			return err
+		}
+	} else {
+		var diags weave.Diagnostics
+		var err error
+		from, diags, err = readDiffSchema(c, args[0])
+		if r != nil {
+			r.Diagnostics = diags
+		}
+		if err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+	to, diags, err := readDiffSchema(c, args[0])
+	if r != nil {
+		r.Diagnostics = append(r.Diagnostics, diags...)
+	}
+	if err != nil {
+		return err
+	}
+
+	diff, err := weave.DiffSchemaJSON(from, to)
+	if err != nil {
+		return err
+	}
+	if owner := c.String("owner"); owner != "" {
+		diff = diff.FilterOwner(owner)
+	}
+	if r != nil {
+		r.Changes = diff
+		return nil
+	}
+
+	if format == "json" {
+		if diff == nil {
+			diff = weave.SchemaDiff{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return fmt.Errorf("error writing diff: %v", err)
+		}
+	} else if len(diff) == 0 {
+		fmt.Println("No changes")
+	} else {
+		for _, change := range diff {
+			fmt.Println(change)
+		}
+	}
+
+	if c.Bool("exit-code") && len(diff) > 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// readDiffSchema reads a schema to compare with readSchemaJSON and normalizes it
+func readDiffSchema(c *cli.Command, src string) ([]byte, weave.Diagnostics, error) {
+	data, diags, err := readSchemaJSON(c, src)
+	if err != nil {
+		return nil, diags, err
+	}
+	data, parseDiags, err := normalizeSchemaJSON(c, data, src)
+	return data, append(diags, parseDiags...), err
+}
+
+// normalizeSchemaJSON parses a schema with weave.ParseSchema and writes it back with the
+// defaults Weaviate fills in, so settings one side leaves to the server, like an export
+// from a cluster has them, aren't reported as changes
+func normalizeSchemaJSON(c *cli.Command, data []byte, src string) ([]byte, weave.Diagnostics, error) {
+	schema, diags, err := weave.ParseSchema(bytes.NewReader(data))
+	if err != nil {
+		return nil, diags, fmt.Errorf("error reading %s: %v", src, err)
+	}
+	if !jsonResult(c) {
+		printDiagnostics(diags)
+	}
+	if diags.HasErrors() {
+		return nil, diags, fmt.Errorf("%s doesn't match the Weaviate class spec", src)
+	}
+	data, err = schema.ToJSON(false)
+	return data, diags, err
+}
+
+// clusterDrift is the drift of one cluster in the JSON output of weave diff
+type clusterDrift struct {
+	Cluster string           `json:"cluster"`
+	Changes weave.SchemaDiff `json:"changes"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// diffClusters compares the schema given as the only argument with every cluster, listing
+// what applying it would change on each
+func diffClusters(ctx context.Context, c *cli.Command, r *result, targets []clusterTarget, format string) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("one schema to compare the clusters with is required")
+	}
+	to, diags, err := readSchemaJSON(c, c.Args().First())
+	if r != nil {
+		r.Diagnostics = diags
+	}
+	if err != nil {
+		return err
+	}
+
+	var drifts []clusterDrift
+	var failed []string
+	drifted := false
+	for _, target := range targets {
+		diff, err := weave.ClusterDrift(ctx, target.client, to)
+		if owner := c.String("owner"); owner != "" {
+			diff = diff.FilterOwner(owner)
+		}
+		if diff == nil {
+			diff = weave.SchemaDiff{}
+		}
+		drift := clusterDrift{Cluster: target.name, Changes: diff}
+		if err != nil {
+			drift.Error = err.Error()
+			failed = append(failed, target.name)
+		}
+		drifted = drifted || len(diff) > 0
+		drifts = append(drifts, drift)
+	}
+
+	if r != nil {
+		r.Clusters = drifts
+	} else if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(drifts); err != nil {
+			return fmt.Errorf("error writing diff: %v", err)
+		}
+	} else {
+		for _, drift := range drifts {
+			switch {
+			case drift.Error != "":
+				fmt.Printf("%s: %s\n", drift.Cluster, drift.Error)
+			case len(drift.Changes) == 0:
+				fmt.Printf("%s: no changes\n", drift.Cluster)
+			default:
+				fmt.Printf("%s: %d changes\n", drift.Cluster, len(drift.Changes))
+				for _, change := range drift.Changes {
+					fmt.Printf("  %s\n", change)
+				}
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("couldn't compare %s", strings.Join(failed, ", "))
+	}
+	if c.Bool("exit-code") && drifted {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func docsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "docs",
+		Usage: "Generate Markdown documentation of the schema from Go struct definitions",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output file for the documentation (defaults to stdout)",
+			},
+			&cli.BoolFlag{
+				Name:  "mermaid",
+				Usage: "Include a Mermaid ER diagram of the classes and their references",
+			},
+			&cli.StringSliceFlag{
+				Name:  "class",
+				Usage: "Only generate these classes, e.g. --class Article,Author",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-class",
+				Usage: "Leave these classes out",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+		},
+		Action: generateDocs,
+	}
+}
+
+func generateDocs(ctx context.Context, c *cli.Command) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output := c.String("output"); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := weave.WriteMarkdown(w, schema, c.Bool("mermaid")); err != nil {
+		return err
+	}
+
+	return checkDiagnostics(diags)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+// clusterFlags are shared by the commands talking to a Weaviate cluster
+func clusterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "host",
+			Usage:    "Weaviate host, e.g. localhost:8080",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "scheme",
+			Usage: "Weaviate URL scheme",
+			Value: "http",
+		},
+		&cli.StringFlag{
+			Name:    "api-key",
+			Usage:   "Weaviate API key",
+			Sources: cli.EnvVars("WEAVIATE_API_KEY"),
+		},
+	}
+}
+
+func newClusterClient(c *cli.Command) *weave.ClusterClient {
+	return weave.NewClusterClient(c.String("host"), c.String("scheme"), c.String("api-key"))
+}
+
+func dumpCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "dump",
+		Usage:     "Export all objects of the generated schema's classes, with vectors, to NDJSON shards",
+		ArgsUsage: "<source directory or dir/...>...",
+		Flags: append(clusterFlags(),
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "Directory to write the dump to",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "page-size",
+				Usage: "Objects per cursor request",
+				Value: weave.DefaultDumpPageSize,
+			},
+			&cli.IntFlag{
+				Name:  "shard-size",
+				Usage: "Objects per shard file",
+				Value: weave.DefaultDumpShardSize,
+			},
+			&cli.StringSliceFlag{
+				Name:  "class",
+				Usage: "Only dump these classes, e.g. --class Article,Author",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-class",
+				Usage: "Leave these classes out",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+		),
+		Action: dumpObjects,
+	}
+}
+
+func restoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "restore",
+		Usage: "Import a dump written by weave dump with the batch API; the schema must already exist",
+		Flags: append(clusterFlags(),
+			&cli.StringFlag{
+				Name:     "input",
+				Aliases:  []string{"i"},
+				Usage:    "Directory holding the dump",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "Objects per batch request",
+				Value: weave.DefaultRestoreBatch,
+			},
+		),
+		Action: restoreObjects,
+	}
+}
+
+func dumpObjects(ctx context.Context, c *cli.Command) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return err
+	}
+
+	counts, err := weave.Dump(ctx, newClusterClient(c), schema, c.String("output"), weave.DumpOptions{
+		PageSize:  int(c.Int("page-size")),
+		ShardSize: int(c.Int("shard-size")),
+	})
+	if err != nil {
+		return err
+	}
+
+	printCounts("Dumped", counts)
+	return nil
+}
+
+func restoreObjects(ctx context.Context, c *cli.Command) error {
+	counts, err := weave.Restore(ctx, newClusterClient(c), c.String("input"), int(c.Int("batch-size")))
+	if err != nil {
+		return err
+	}
+
+	printCounts("Restored", counts)
+	return nil
+}
+
+// printCounts reports the number of objects per class
+func printCounts(verb string, counts map[string]int) {
+	for _, class := range slices.Sorted(maps.Keys(counts)) {
+		logf("%s %d %s objects", verb, counts[class], class)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func generateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "generate",
+		Usage:     "Generate additional targets from the schema with generator plugins, e.g. a client in another language",
+		ArgsUsage: "<source directory or dir/...>...",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:     "plugin",
+				Usage:    "Generator plugin to run, as name or name:parameter; name is weave-gen-<name> on the PATH, an executable's path, or a Go plugin's .so file",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "Output directory for the generated files",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "class",
+				Usage: "Only generate these classes, e.g. --class Article,Author",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-class",
+				Usage: "Leave these classes out",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+		},
+		Action: runGenerators,
+	}
+}
+
+func runGenerators(ctx context.Context, c *cli.Command) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return err
+	}
+
+	for _, spec := range c.StringSlice("plugin") {
+		name, parameter, _ := strings.Cut(spec, ":")
+		gen, err := weave.LoadGenerator(name)
+		if err != nil {
+			return err
+		}
+		paths, err := weave.RunGenerator(ctx, gen, schema, parameter, c.String("output"))
+		if err != nil {
+			return fmt.Errorf("plugin %s: %v", name, err)
+		}
+		for _, path := range paths {
+			debugf("Generated %s", path)
+		}
+		logf("Plugin %s generated %d files", name, len(paths))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func initCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "init",
+		Usage:     "Pick the structs of a package to mark with +weave and scaffold weave.yaml",
+		ArgsUsage: "[directory]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Overwrite an existing " + weave.DefaultConfigFile,
+			},
+		},
+		Action: initProject,
+	}
+}
+
+func initProject(ctx context.Context, c *cli.Command) error {
+	dir := "."
+	if c.Args().Len() > 0 {
+		dir = c.Args().First()
+	}
+
+	configPath := filepath.Join(dir, weave.DefaultConfigFile)
+	if _, err := os.Stat(configPath); err == nil && !c.Bool("force") {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it", configPath)
+	}
+
+	structs, err := weave.ListStructs(dir)
+	if err != nil {
+		return err
+	}
+	if len(structs) == 0 {
+		return fmt.Errorf("no struct types found in %s", dir)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+
+	fmt.Printf("Structs in %s:\n", dir)
+	for i, s := range structs {
+		marked := ""
+		if s.Marked {
+			marked = " (already marked)"
+		}
+		fmt.Printf("  %d. %s%s\n", i+1, s.Name, marked)
+	}
+	picked, err := pickStructs(prompt(in, "Structs to mark (numbers or names separated by commas, or all)", "all"), structs)
+	if err != nil {
+		return err
+	}
+
+	vectorizer := prompt(in, "Vectorizer, e.g. text2vec-openai or none", "none")
+	model := ""
+	if vectorizer != "none" {
+		model = prompt(in, "Model for "+vectorizer+" (empty for the module default)", "")
+	}
+	version := prompt(in, "Weaviate version the schema targets, e.g. 1.25 (empty for any)", "")
+
+	changed, err := weave.MarkStructs(dir, picked, "vectorizer="+vectorizer)
+	if err != nil {
+		return err
+	}
+	for _, path := range changed {
+		logf("Marked structs in %s", path)
+	}
+
+	if err := os.WriteFile(configPath, []byte(scaffoldConfig(vectorizer, model, version)), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", configPath, err)
+	}
+	logf("Wrote %s", configPath)
+	return nil
+}
+
+// prompt asks a question on stdout and returns the trimmed answer, or def when it's empty
+func prompt(in *bufio.Reader, question, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	answer, _ := in.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def
+	}
+	return answer
+}
+
+// pickStructs resolves the answer to the struct prompt to struct names
+func pickStructs(answer string, structs []weave.StructInfo) ([]string, error) {
+	var names []string
+	if answer == "all" {
+		for _, s := range structs {
+			names = append(names, s.Name)
+		}
+		return names, nil
+	}
+
+	for _, item := range strings.Split(answer, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(item); err == nil {
+			if n < 1 || n > len(structs) {
+				return nil, fmt.Errorf("no struct numbered %d", n)
+			}
+			names = append(names, structs[n-1].Name)
+			continue
+		}
+		if !slices.ContainsFunc(structs, func(s weave.StructInfo) bool { return s.Name == item }) {
+			return nil, fmt.Errorf("no struct named %s", item)
+		}
+		names = append(names, item)
+	}
+	return names, nil
+}
+
+// scaffoldConfig renders weave.yaml with the wizard's answers
+func scaffoldConfig(vectorizer, model, version string) string {
+	var b strings.Builder
+	b.WriteString("# weave project config, written by weave init\n\n")
+	if version != "" {
+		b.WriteString("# Weaviate release the schema targets; features it lacks are reported as errors\n")
+		fmt.Fprintf(&b, "weaviateVersion: %q\n\n", version)
+	}
+	if model != "" {
+		b.WriteString("defaults:\n")
+		b.WriteString("  # merged into every class that doesn't configure the module itself\n")
+		b.WriteString("  moduleConfig:\n")
+		fmt.Fprintf(&b, "    %s:\n", vectorizer)
+		fmt.Fprintf(&b, "      model: %s\n\n", model)
+	}
+	b.WriteString("# property names of fields without a json tag: camelCase, snake_case or keep\n")
+	b.WriteString("naming: camelCase\n")
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func lintCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "Check the schema generated from Go structs against the lint rules",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text or sarif",
+				Value:   "text",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output file for the report (defaults to stdout)",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+			&cli.StringFlag{
+				Name:  "owner",
+				Usage: "Only report findings about classes owned by this team, see +weave:owner:",
+			},
+			outputFormatFlag(),
+		},
+		Action: withResult(lintSchema),
+	}
+}
+
+func lintSchema(ctx context.Context, c *cli.Command, r *result) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if format != "text" && format != "sarif" {
+		return fmt.Errorf("unknown format %q (expected text or sarif)", format)
+	}
+	if format == "sarif" && r != nil {
+		return fmt.Errorf("--format sarif and --output-format json can't be combined")
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	// Generation problems are reported alongside the lint findings
+	schema, diags, err := weave.GenerateWeaviateSchemaFromSources(srcs, cfg)
+	if err != nil {
+		return fmt.Errorf("error generating schema: %v", err)
+	}
+
+	findings, err := weave.Lint(schema, cfg.Lint)
+	if err != nil {
+		return fmt.Errorf("error in lint config: %v", err)
+	}
+	diags = append(diags, findings...)
+	if owner := c.String("owner"); owner != "" {
+		diags = diags.FilterOwner(owner)
+	}
+
+	if c.Bool("strict") {
+		diags = diags.Strict()
+	}
+	if r != nil {
+		r.Diagnostics = diags
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if output := c.String("output"); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "sarif" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting working directory: %v", err)
+		}
+		if err := weave.WriteSARIF(w, diags, wd); err != nil {
+			return fmt.Errorf("error writing SARIF report: %v", err)
+		}
+	} else {
+		for _, diag := range diags {
+			fmt.Fprintln(w, diag)
+		}
+	}
+
+	if diags.HasErrors() {
+		return fmt.Errorf("lint failed with errors")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+// Verbosity levels set by -q and -v. Data the commands produce, like schemas, diffs and
+// reports, goes to stdout at every level; progress messages and diagnostics go to stderr.
+const (
+	levelQuiet   = -1 // errors only
+	levelNormal  = 0  // progress messages and warnings too
+	levelVerbose = 1  // what weave reads and writes too
+)
+
+var verbosity = levelNormal
+
+// verbosityFlags are the root command's -v and -q, which every subcommand accepts too
+func verbosityFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Usage:   "Also log the config file read and the files written",
+			Action:  setVerbosity,
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Usage:   "Only report errors",
+			Action:  setVerbosity,
+		},
+	}
+}
+
+// setVerbosity applies -v or -q, given before or after the subcommand
+func setVerbosity(ctx context.Context, c *cli.Command, _ bool) error {
+	if c.Bool("verbose") && c.Bool("quiet") {
+		return fmt.Errorf("--verbose and --quiet can't be combined")
+	}
+	switch {
+	case c.Bool("verbose"):
+		verbosity = levelVerbose
+	case c.Bool("quiet"):
+		verbosity = levelQuiet
+	}
+	return nil
+}
+
+// logf writes a progress message to stderr unless -q was given
+func logf(format string, args ...interface{}) {
+	if verbosity >= levelNormal {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// debugf writes a message to stderr when -v was given
+func debugf(format string, args ...interface{}) {
+	if verbosity >= levelVerbose {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// printDiagnostics writes diagnostics to stderr, leaving out the warnings when -q was given
+func printDiagnostics(diags weave.Diagnostics) {
+	for _, diag := range diags {
+		if diag.Severity == weave.SeverityWarning && verbosity < levelNormal {
+			continue
+		}
+		fmt.Fprintln(os.Stderr, diag)
+	}
+}
@@ -3,7 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/urfave/cli/v3"
 
@@ -13,6 +17,12 @@ import (
 func main() {
 	// Define command line flags
 	cmd := &cli.Command{
+		Name:  "weave",
+		Usage: "Generate Weaviate schemas and CRUD code from Go structs",
+		Flags: verbosityFlags(),
+
+		// Candidates for the scripts of weave completion
+		EnableShellCompletion: true,
 		Commands: []*cli.Command{
 			{
 				Name:  "schema",
@@ -28,9 +38,48 @@ func main() {
 						Aliases: []string{"o"},
 						Usage:   "Output file for the generated schema",
 					},
+					&cli.BoolFlag{
+						Name:  "metadata",
+						Usage: "Embed the x-weave metadata block (weave version, git commit, source hash, generation time)",
+					},
+					&cli.BoolFlag{
+						Name:  "codegen",
+						Usage: "Embed the x-weave-go block weave crud --from-schema generates code from",
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only generate these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Treat warnings as errors",
+					},
+					&cli.StringFlag{
+						Name:  "weaviate-version",
+						Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+					},
+					&cli.StringFlag{
+						Name:  "graph",
+						Usage: "Also write the class reference graph to this file, as Graphviz (.dot, .gv) or Mermaid (.mmd, .mermaid)",
+					},
+					outputFormatFlag(),
 				},
 
-				Action: generateSchema,
+				Action: withResult(generateSchema),
 			},
 			{
 				Name:  "crud",
@@ -41,45 +90,144 @@ func main() {
 						Aliases: []string{"o"},
 						Usage:   "Output directory for the generated package",
 					},
+					&cli.StringFlag{
+						Name:  "from-schema",
+						Usage: "Generate from a schema file written by weave schema --codegen, or - for stdin, instead of the sources",
+					},
 					&cli.BoolFlag{
 						Name:    "include-types",
 						Aliases: []string{"t"},
 						Usage:   "Include useful helper types",
 					},
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Compile the generated package with go build after generation",
+					},
+					&cli.BoolFlag{
+						Name:  "otel",
+						Usage: "Generate OpenTelemetry tracing and metrics middleware",
+					},
+					&cli.BoolFlag{
+						Name:  "cache",
+						Usage: "Generate middleware caching reads by ID",
+					},
+					&cli.BoolFlag{
+						Name:  "handlers",
+						Usage: "Generate net/http handlers serving the CRUD operations of every class",
+					},
+					&cli.BoolFlag{
+						Name:  "testcode",
+						Usage: "Generate a testcontainers-go harness and fixture builders for integration tests",
+					},
+					&cli.BoolFlag{
+						Name:  "embedded",
+						Usage: "Generate NewEmbeddedClient running Weaviate as a local process, for tests without Docker",
+					},
+					&cli.BoolFlag{
+						Name:  "bench",
+						Usage: "Generate a benchmark per class measuring inserts, batch inserts, reads and vector searches",
+					},
+					&cli.BoolFlag{
+						Name:  "metadata",
+						Usage: "Generate weave_metadata.go with the weave version, git commit, source hash and generation time",
+					},
+					&cli.BoolFlag{
+						Name:  "lint-queries",
+						Usage: "Warn where the generated query helpers can't work against the schema, e.g. keyword searches of classes without text",
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only generate these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Treat warnings as errors",
+					},
+					&cli.StringFlag{
+						Name:  "weaviate-version",
+						Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+					},
 				},
 				Action: generateCrud,
 			},
+			initCommand(),
+			annotateCommand(),
+			lintCommand(),
+			docsCommand(),
+			generateCommand(),
+			validateCommand(),
+			diffCommand(),
+			mergeCommand(),
+			applyCommand(),
+			dumpCommand(),
+			restoreCommand(),
+			backupCommand(),
+			modulesCommand(),
+			migrateCommand(),
+			reindexCommand(),
+			completionCommand(),
+			manCommand(),
 		}}
 
+	// Exit codes are handled by cli; anything else is a failure worth one line, not a stack trace
 	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "weave: %v\n", err)
+		os.Exit(exitErrors)
 	}
 }
 
-func generateSchema(ctx context.Context, c *cli.Command) error {
-	srcDir := c.Args().First()
-	if srcDir == "" {
-		return fmt.Errorf("source directory is required")
+func generateSchema(ctx context.Context, c *cli.Command, r *result) error {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
 	}
 
 	output := c.String("output")
 
 	pretty := c.Bool("pretty")
 
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if c.Bool("metadata") {
+		cfg.Output.Metadata = true
+	}
+
 	// Generate the schema
-	schema, err := weave.GenerateWeaviateSchema(srcDir)
+	schema, diags, err := buildSchema(c, cfg, srcs)
 	if err != nil {
-		return fmt.Errorf("error generating schema: %v", err)
+		return err
 	}
 
 	// Marshal to JSON
-	jsonOutput, err := schema.ToJSON(pretty)
+	toJSON := schema.ToJSON
+	if c.Bool("codegen") {
+		toJSON = schema.ToCodegenJSON
+	}
+	jsonOutput, err := toJSON(pretty)
 	if err != nil {
 		return fmt.Errorf("error marshaling schema to JSON: %v", err)
 	}
 
 	// Output the schema
-	if output == "" {
+	if output == "" && r != nil {
+		r.Schema = jsonOutput
+	} else if output == "" {
 		// Output to stdout
 		fmt.Println(string(jsonOutput))
 	} else {
@@ -88,40 +236,245 @@ func generateSchema(ctx context.Context, c *cli.Command) error {
 		if err != nil {
 			return fmt.Errorf("error writing to output file: %v", err)
 		}
-		fmt.Printf("Schema successfully written to %s\n", output)
+		if r != nil {
+			r.Output = output
+		} else {
+			logf("Schema successfully written to %s", output)
+		}
 	}
 
-	return nil
+	if graph := c.String("graph"); graph != "" {
+		if err := writeGraph(schema, graph); err != nil {
+			return err
+		}
+	}
+
+	if r != nil {
+		r.Diagnostics = diags
+		return nil
+	}
+	return checkDiagnostics(diags)
 }
 
 func generateCrud(ctx context.Context, c *cli.Command) error {
-	srcDir := c.Args().First()
-	if srcDir == "" {
-		return fmt.Errorf("source directory is required")
+	var srcs []string
+	if c.String("from-schema") == "" {
+		var err error
+		if srcs, err = sourceDirs(c); err != nil {
+			return err
+		}
 	}
 
-	output := c.String("output")
-	if output == "" {
-		output = srcDir
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if c.Bool("otel") {
+		cfg.Output.OpenTelemetry = true
+	}
+	if c.Bool("cache") {
+		cfg.Output.Cache = true
+	}
+	if c.Bool("testcode") {
+		cfg.Output.TestCode = true
+	}
+	if c.Bool("embedded") {
+		cfg.Output.Embedded = true
+	}
+	if c.Bool("bench") {
+		cfg.Output.Benchmarks = true
+	}
+	if c.Bool("handlers") {
+		cfg.Output.Handlers = true
+	}
+	if c.Bool("metadata") {
+		cfg.Output.Metadata = true
 	}
 
-	includeTypes := c.Bool("include-types")
-
-	schema, err := weave.GenerateWeaviateSchema(srcDir)
+	var schema *weave.WeaviateSchemaDefinition
+	var diags weave.Diagnostics
+	if from := c.String("from-schema"); from != "" {
+		schema, err = readCodegenSchema(c, from)
+	} else {
+		schema, diags, err = buildSchema(c, cfg, srcs)
+	}
 	if err != nil {
-		return fmt.Errorf("error generating schema: %v", err)
+		return err
+	}
+
+	if c.Bool("lint-queries") {
+		findings, err := weave.LintQueries(schema, cfg.Lint)
+		if err != nil {
+			return fmt.Errorf("error in lint config: %v", err)
+		}
+		if c.Bool("strict") {
+			findings = findings.Strict()
+		}
+		printDiagnostics(findings)
+		diags = append(diags, findings...)
+	}
+
+	// Without --output, each package gets its code next to its types
+	packages := schema.Packages()
+	if output := c.String("output"); output != "" || len(packages) == 0 {
+		if len(packages) > 1 {
+			return fmt.Errorf("classes are declared in %d packages; --output needs a single package", len(packages))
+		}
+		if output == "" && len(srcs) == 0 {
+			return fmt.Errorf("the schema has no classes; --output is required")
+		}
+		if output == "" {
+			output = srcs[0]
+		}
+		packages = map[string]*weave.WeaviateSchemaDefinition{output: schema}
 	}
 
-	packageName, err := weave.GenerateCRUDCode(schema, output)
+	for _, output := range slices.Sorted(maps.Keys(packages)) {
+		if err := generatePackage(c, cfg, packages[output], output); err != nil {
+			return err
+		}
+	}
+
+	return checkDiagnostics(diags)
+}
+
+// generatePackage generates the CRUD code of one package into output
+func generatePackage(c *cli.Command, cfg *weave.Config, schema *weave.WeaviateSchemaDefinition, output string) error {
+	packageName, err := weave.GenerateCRUDCodeWithConfig(schema, output, cfg)
 	if err != nil {
 		return fmt.Errorf("error generating crud code: %v", err)
 	}
+	debugf("Generated package %s in %s", packageName, output)
 
-	if includeTypes {
-		err = weave.GenerateTypes(packageName, output)
+	if c.Bool("include-types") {
+		err = weave.GenerateTypesWithConfig(packageName, output, cfg)
 		if err != nil {
 			return fmt.Errorf("error generating types: %v", err)
 		}
 	}
+
+	if c.Bool("check") {
+		if err := weave.CheckGeneratedCode(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCodegenSchema reads the schema weave crud --from-schema generates code from, keeping
+// the classes selected with --class and --exclude-class
+func readCodegenSchema(c *cli.Command, path string) (*weave.WeaviateSchemaDefinition, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema: %v", err)
+	}
+
+	schema, err := weave.SchemaFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.FilterClasses(c.StringSlice("class"), c.StringSlice("exclude-class")); err != nil {
+		return nil, err
+	}
+	debugf("Read %d classes from %s", len(schema.Classes), path)
+	return schema, nil
+}
+
+// writeGraph writes the reference graph in the format matching the file extension
+func writeGraph(schema *weave.WeaviateSchemaDefinition, path string) error {
+	format, err := weave.GraphFormatForFile(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating graph file: %v", err)
+	}
+	defer f.Close()
+
+	if err := weave.WriteGraph(f, schema, format); err != nil {
+		return fmt.Errorf("error writing graph: %v", err)
+	}
+	return nil
+}
+
+// sourceDirs returns the source directories and "dir/..." patterns given as arguments
+func sourceDirs(c *cli.Command) ([]string, error) {
+	srcs := c.Args().Slice()
+	if len(srcs) == 0 {
+		return nil, fmt.Errorf("source directory is required")
+	}
+	return srcs, nil
+}
+
+// buildSchema generates the schema for the sources, keeps the classes selected with --class
+// and --exclude-class, and reports its diagnostics on stderr unless they go in the
+// --output-format json result.
+// The schema holds every class that could be generated even when errors were found,
+// so callers write their output before failing with checkDiagnostics.
+func buildSchema(c *cli.Command, cfg *weave.Config, srcs []string) (*weave.WeaviateSchemaDefinition, weave.Diagnostics, error) {
+	schema, diags, err := weave.GenerateWeaviateSchemaFromSources(srcs, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating schema: %v", err)
+	}
+
+	if err := schema.FilterClasses(c.StringSlice("class"), c.StringSlice("exclude-class")); err != nil {
+		return nil, nil, err
+	}
+	debugf("Generated %d classes from %s", len(schema.Classes), strings.Join(srcs, ", "))
+
+	if c.Bool("strict") {
+		diags = diags.Strict()
+	}
+
+	if !jsonResult(c) {
+		printDiagnostics(diags)
+	}
+
+	return schema, diags, nil
+}
+
+// loadConfig loads the config file named by --config, falling back to
+// weave.yaml in the working directory when it exists, and selects the --env overlay
+func loadConfig(c *cli.Command) (*weave.Config, error) {
+	path := c.String("config")
+	if path == "" {
+		if _, err := os.Stat(weave.DefaultConfigFile); err == nil {
+			path = weave.DefaultConfigFile
+		}
+	}
+
+	cfg := &weave.Config{}
+	if path != "" {
+		var err error
+		if cfg, err = weave.LoadConfig(path); err != nil {
+			return nil, err
+		}
+		debugf("Using config file %s", path)
+	}
+
+	if version := c.String("weaviate-version"); version != "" {
+		cfg.WeaviateVersion = version
+	}
+	if env := c.String("env"); env != "" {
+		if err := cfg.UseEnvironment(env); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// checkDiagnostics fails the command when errors were reported; buildSchema already printed them
+func checkDiagnostics(diags weave.Diagnostics) error {
+	if diags.HasErrors() {
+		return fmt.Errorf("generation failed, see the errors above")
+	}
 	return nil
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func manCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "man",
+		Usage: "Print the weave(1) man page, generated from the commands and their flags",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output file for the man page, e.g. /usr/local/share/man/man1/weave.1",
+			},
+		},
+		Action: printManPage,
+	}
+}
+
+func printManPage(ctx context.Context, c *cli.Command) error {
+	var w io.Writer = os.Stdout
+	if output := c.String("output"); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeManPage(w, c.Root()); err != nil {
+		return fmt.Errorf("error writing man page: %v", err)
+	}
+	return nil
+}
+
+// writeManPage renders the command tree as a roff man page, one subsection per command
+func writeManPage(w io.Writer, root *cli.Command) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", strings.ToUpper(root.Name), root.Name, roffEscape(weave.Version()))
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", root.Name, roffEscape(root.Usage))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n[\\fIglobal options\\fR] \\fIcommand\\fR [\\fIcommand options\\fR] [\\fIarguments\\fR]\n", root.Name)
+	b.WriteString(".SH GLOBAL OPTIONS\n")
+	writeManFlags(&b, root.VisibleFlags())
+	b.WriteString(".SH COMMANDS\n")
+	for _, cmd := range root.VisibleCommands() {
+		writeManCommand(&b, root.Name, cmd)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeManCommand writes the subsection of a command, followed by its subcommands
+func writeManCommand(b *strings.Builder, parent string, cmd *cli.Command) {
+	if cmd.Name == "help" {
+		return
+	}
+	name := parent + " " + cmd.Name
+	fmt.Fprintf(b, ".SS %s", name)
+	if cmd.ArgsUsage != "" {
+		fmt.Fprintf(b, " %s", roffEscape(cmd.ArgsUsage))
+	}
+	fmt.Fprintf(b, "\n%s\n", roffEscape(cmd.Usage))
+	writeManFlags(b, cmd.VisibleFlags())
+	for _, sub := range cmd.VisibleCommands() {
+		writeManCommand(b, name, sub)
+	}
+}
+
+// writeManFlags writes a tagged paragraph per flag with its names, default and environment variables
+func writeManFlags(b *strings.Builder, flags []cli.Flag) {
+	for _, flag := range flags {
+		var names []string
+		for _, name := range flag.Names() {
+			if name == "help" || name == "h" {
+				continue
+			}
+			dashes := "\\-\\-"
+			if len(name) == 1 {
+				dashes = "\\-"
+			}
+			names = append(names, "\\fB"+dashes+roffEscape(name)+"\\fR")
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		usage := ""
+		if doc, ok := flag.(cli.DocGenerationFlag); ok {
+			usage = roffEscape(doc.GetUsage())
+			if doc.TakesValue() {
+				names[0] += " \\fIvalue\\fR"
+				if value := doc.GetValue(); value != "" && value != `""` && value != "[]" {
+					usage += " (default: " + roffEscape(value) + ")"
+				}
+			}
+			if envs := doc.GetEnvVars(); len(envs) > 0 {
+				usage += " (env: " + strings.Join(envs, ", ") + ")"
+			}
+		}
+		fmt.Fprintf(b, ".TP\n%s\n%s\n", strings.Join(names, ", "), usage)
+	}
+}
+
+// roffEscape keeps text from being read as roff requests or escapes
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
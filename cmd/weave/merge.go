@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func mergeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "merge",
+		Usage:     "Combine independently generated schema fragments into one schema",
+		ArgsUsage: "<schema.json>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the combined schema to (defaults to stdout)",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+		},
+		Action: mergeSchemas,
+	}
+}
+
+func mergeSchemas(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() == 0 {
+		return fmt.Errorf("schema fragments to merge are required")
+	}
+
+	var fragments []weave.SchemaFragment
+	for _, path := range c.Args().Slice() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading schema file: %v", err)
+		}
+		fragments = append(fragments, weave.SchemaFragment{Name: path, JSON: data})
+	}
+
+	merged, diags, err := weave.MergeSchemaJSON(fragments)
+	if err != nil {
+		return err
+	}
+	if c.Bool("strict") {
+		diags = diags.Strict()
+	}
+	printDiagnostics(diags)
+	if diags.HasErrors() {
+		return fmt.Errorf("merge failed, see the errors above")
+	}
+
+	if output := c.String("output"); output != "" {
+		if err := os.WriteFile(output, merged, 0644); err != nil {
+			return fmt.Errorf("error writing schema: %v", err)
+		}
+		return nil
+	}
+	_, err = os.Stdout.Write(merged)
+	return err
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Migrate the data on a cluster to the generated schema, e.g. copying renamed classes",
+		Commands: []*cli.Command{
+			{
+				Name:      "plan",
+				Usage:     "Show the migration steps without running them",
+				ArgsUsage: "<source directory or dir/...>...",
+				Flags: append(clusterFlags(),
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "Output format: text or json",
+						Value:   "text",
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only migrate these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Treat warnings as errors",
+					},
+				),
+				Action: planMigration,
+			},
+			{
+				Name:      "apply",
+				Usage:     "Plan the migration and run its steps",
+				ArgsUsage: "<source directory or dir/...>...",
+				Flags: append(clusterFlags(),
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Objects read per cursor request",
+						Value: weave.DefaultDumpPageSize,
+					},
+					&cli.IntFlag{
+						Name:  "batch-size",
+						Usage: "Objects written per batch request",
+						Value: weave.DefaultRestoreBatch,
+					},
+					&cli.StringSliceFlag{
+						Name:  "class",
+						Usage: "Only migrate these classes, e.g. --class Article,Author",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-class",
+						Usage: "Leave these classes out",
+					},
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+					},
+					&cli.StringFlag{
+						Name:    "env",
+						Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+						Sources: cli.EnvVars("WEAVE_ENV"),
+					},
+					&cli.BoolFlag{
+						Name:  "strict",
+						Usage: "Treat warnings as errors",
+					},
+				),
+				Action: applyMigration,
+			},
+		},
+	}
+}
+
+func planMigration(ctx context.Context, c *cli.Command) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (expected text or json)", format)
+	}
+
+	schema, err := migrationSchema(c)
+	if err != nil {
+		return err
+	}
+	plan, err := weave.PlanMigration(ctx, newClusterClient(c), schema)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		if plan.Steps == nil {
+			plan.Steps = []weave.MigrationStep{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			return fmt.Errorf("error writing plan: %v", err)
+		}
+		return nil
+	}
+
+	if len(plan.Steps) == 0 {
+		fmt.Println("Nothing to migrate")
+		return nil
+	}
+	for i, step := range plan.Steps {
+		fmt.Printf("%d. %s\n", i+1, step)
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, c *cli.Command) error {
+	schema, err := migrationSchema(c)
+	if err != nil {
+		return err
+	}
+	client := newClusterClient(c)
+	plan, err := weave.PlanMigration(ctx, client, schema)
+	if err != nil {
+		return err
+	}
+	if len(plan.Steps) == 0 {
+		logf("Nothing to migrate")
+		return nil
+	}
+
+	result, err := weave.ApplyMigration(ctx, client, schema, plan, weave.MigrationOptions{
+		PageSize:  int(c.Int("page-size")),
+		BatchSize: int(c.Int("batch-size")),
+	})
+	for _, step := range result.Done {
+		logf("%s", step)
+	}
+	for _, class := range slices.Sorted(maps.Keys(result.Copied)) {
+		logf("Copied %d objects to %s", result.Copied[class], class)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Migration incomplete, run weave migrate apply again to resume")
+		return err
+	}
+	return nil
+}
+
+// migrationSchema builds the schema a migration moves the cluster's data to
+func migrationSchema(c *cli.Command) (*weave.WeaviateSchemaDefinition, error) {
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func modulesCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "modules",
+		Usage:     "List the Weaviate modules the generated schema depends on, for the cluster's ENABLE_MODULES",
+		ArgsUsage: "<source directory or dir/...>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   "Output format: text or json",
+				Value:   "text",
+			},
+			&cli.StringSliceFlag{
+				Name:  "backup",
+				Usage: "Also list the backup modules of these backends, e.g. --backup s3",
+			},
+			&cli.StringSliceFlag{
+				Name:  "class",
+				Usage: "Only list the modules of these classes, e.g. --class Article,Author",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-class",
+				Usage: "Leave these classes out",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file (defaults to " + weave.DefaultConfigFile + " when present)",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+		},
+		Action: listModules,
+	}
+}
+
+func listModules(ctx context.Context, c *cli.Command) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (expected text or json)", format)
+	}
+
+	srcs, err := sourceDirs(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, srcs)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return err
+	}
+
+	modules := schema.Modules()
+	for _, backend := range c.StringSlice("backup") {
+		if !slices.Contains(weave.BackupBackends, backend) {
+			return fmt.Errorf("unknown backup backend %q (expected one of %s)", backend, strings.Join(weave.BackupBackends, ", "))
+		}
+		modules = append(modules, weave.ModuleUse{Module: "backup-" + backend, Kind: "backup"})
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(modules); err != nil {
+			return fmt.Errorf("error writing modules: %v", err)
+		}
+		return nil
+	}
+
+	if len(modules) == 0 {
+		fmt.Println("No modules")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	names := make([]string, len(modules))
+	for i, module := range modules {
+		names[i] = module.Module
+		fmt.Fprintf(w, "%s\t%s\t%s\n", module.Module, module.Kind, strings.Join(module.Classes, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nENABLE_MODULES=%s\n", strings.Join(names, ","))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func reindexCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "reindex",
+		Usage: "Re-insert the objects of a class without their vectors, so its vectorizer recomputes them after a model or config change",
+		Flags: append(clusterFlags(),
+			&cli.StringFlag{
+				Name:     "class",
+				Usage:    "Class to reindex",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "to-class",
+				Usage: "Write the objects to this existing class instead, e.g. one applied with the new vectorizer config",
+			},
+			&cli.StringSliceFlag{
+				Name:  "vector",
+				Usage: "Only recompute these named vectors, keeping the others, e.g. --vector title_v2",
+			},
+			&cli.IntFlag{
+				Name:  "page-size",
+				Usage: "Objects read per cursor request",
+				Value: weave.DefaultDumpPageSize,
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "Objects written per batch request",
+				Value: weave.DefaultRestoreBatch,
+			},
+			&cli.FloatFlag{
+				Name:  "rate",
+				Usage: "Batch requests per second at most, to spare the vectorizer's rate limits; 0 for unlimited",
+			},
+			&cli.StringFlag{
+				Name:  "checkpoint",
+				Usage: "File recording the progress, so running the same reindex again resumes it",
+			},
+		),
+		Action: reindexClass,
+	}
+}
+
+func reindexClass(ctx context.Context, c *cli.Command) error {
+	className := c.String("class")
+	n, err := weave.Reindex(ctx, newClusterClient(c), className, weave.ReindexOptions{
+		ToClass:           c.String("to-class"),
+		Vectors:           c.StringSlice("vector"),
+		PageSize:          int(c.Int("page-size")),
+		BatchSize:         int(c.Int("batch-size")),
+		RequestsPerSecond: c.Float("rate"),
+		Checkpoint:        c.String("checkpoint"),
+	})
+	logf("Reindexed %d %s objects", n, className)
+	if err != nil && c.String("checkpoint") != "" {
+		fmt.Fprintln(os.Stderr, "Reindex incomplete, run the same command again to resume")
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+// Exit codes of the commands run with --output-format json, so scripts can tell the
+// outcomes apart without reading the output
+const (
+	exitClean    = 0
+	exitWarnings = 1
+	exitErrors   = 2
+	exitDrift    = 3
+)
+
+// outputFormatFlag selects the JSON result of weave schema, validate, lint and diff
+func outputFormatFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "output-format",
+		Usage: "Output format of the command's result: text, or json for a single JSON result on stdout and exit codes 0 clean, 1 warnings, 2 errors, 3 drift",
+		Value: "text",
+	}
+}
+
+// result is the JSON document a command run with --output-format json writes to stdout
+type result struct {
+	Command string `json:"command"`
+
+	// Status is clean, warnings, errors or drift, matching ExitCode
+	Status   string `json:"status"`
+	ExitCode int    `json:"exitCode"`
+
+	Diagnostics weave.Diagnostics `json:"diagnostics"`
+	Changes     weave.SchemaDiff  `json:"changes,omitempty"`
+	Clusters    []clusterDrift    `json:"clusters,omitempty"`
+	Schema      json.RawMessage   `json:"schema,omitempty"`
+	Output      string            `json:"output,omitempty"`
+
+	// Error is why the command failed, e.g. a file that couldn't be read
+	Error string `json:"error,omitempty"`
+}
+
+// jsonResult reports whether the command was run with --output-format json
+func jsonResult(c *cli.Command) bool {
+	return c.String("output-format") == "json"
+}
+
+// withResult runs action with a result to fill when the command was run with
+// --output-format json and nil otherwise, then writes the result and exits with its code.
+// Errors action returns become the result's error.
+func withResult(action func(context.Context, *cli.Command, *result) error) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		switch format := c.String("output-format"); format {
+		case "text":
+			return action(ctx, c, nil)
+		case "json":
+		default:
+			return fmt.Errorf("unknown output format %q (expected text or json)", format)
+		}
+
+		r := &result{Command: c.Name}
+		if err := action(ctx, c, r); err != nil {
+			var exit cli.ExitCoder
+			if !errors.As(err, &exit) {
+				r.Error = err.Error()
+			}
+		}
+		return r.write()
+	}
+}
+
+// write sets the status and exit code and writes the result to stdout
+func (r *result) write() error {
+	if r.Diagnostics == nil {
+		r.Diagnostics = weave.Diagnostics{}
+	}
+	switch {
+	case r.Error != "" || r.Diagnostics.HasErrors():
+		r.Status, r.ExitCode = "errors", exitErrors
+	case len(r.Changes) > 0 || r.drifted():
+		r.Status, r.ExitCode = "drift", exitDrift
+	case r.Diagnostics.HasWarnings():
+		r.Status, r.ExitCode = "warnings", exitWarnings
+	default:
+		r.Status, r.ExitCode = "clean", exitClean
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("error writing result: %v", err)
+	}
+	if r.ExitCode != exitClean {
+		return cli.Exit("", r.ExitCode)
+	}
+	return nil
+}
+
+// drifted reports whether any cluster's schema differs
+func (r *result) drifted() bool {
+	for _, drift := range r.Clusters {
+		if len(drift.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
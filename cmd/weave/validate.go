@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/huffduff/weave"
+)
+
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Validate a schema against Weaviate's class spec without a cluster",
+		ArgsUsage: "<schema.json | source directory | ->",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Project config file, used when validating a source directory",
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment whose overlay from the config file's environments applies, e.g. prod",
+				Sources: cli.EnvVars("WEAVE_ENV"),
+			},
+			&cli.StringFlag{
+				Name:  "weaviate-version",
+				Usage: "Weaviate release to target, e.g. 1.25; overrides weaviateVersion in the config file",
+			},
+			outputFormatFlag(),
+		},
+		Action: withResult(validateSchema),
+	}
+}
+
+func validateSchema(ctx context.Context, c *cli.Command, r *result) error {
+	src := c.Args().First()
+	if src == "" {
+		return fmt.Errorf("schema file or source directory is required")
+	}
+
+	data, genDiags, err := readSchemaJSON(c, src)
+	if r != nil {
+		r.Diagnostics = genDiags
+	}
+	if err != nil {
+		return err
+	}
+
+	diags, err := weave.ValidateSchemaJSON(data)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		r.Diagnostics = append(r.Diagnostics, diags...)
+		return nil
+	}
+
+	printDiagnostics(diags)
+	if diags.HasErrors() {
+		return fmt.Errorf("schema doesn't match the Weaviate class spec")
+	}
+
+	logf("Schema is valid")
+	return nil
+}
+
+// readSchemaJSON reads a schema file, stdin for "-", or generates the schema of a source
+// directory, returning the diagnostics of the generation
+func readSchemaJSON(c *cli.Command, src string) ([]byte, weave.Diagnostics, error) {
+	if src == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading schema from stdin: %v", err)
+		}
+		return data, nil, nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading schema: %v", err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading schema file: %v", err)
+		}
+		return data, nil, nil
+	}
+
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema, diags, err := buildSchema(c, cfg, []string{src})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkDiagnostics(diags); err != nil {
+		return nil, diags, err
+	}
+
+	data, err := schema.ToJSON(false)
+	return data, diags, err
+}
@@ -0,0 +1,366 @@
+package weave
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultConfigFile is the project-level config file picked up by the CLI when present
+	DefaultConfigFile = "weave.yaml"
+)
+
+// Config holds project-level settings applied across all generated classes
+type Config struct {
+	Defaults ClassDefaults `yaml:"defaults"`
+
+	// Types maps Go types to Weaviate data types, overriding the built-in mappings
+	Types TypeRegistry `yaml:"types"`
+
+	Output OutputConfig `yaml:"output"`
+
+	// Naming derives property names from fields without a json tag: camelCase (the
+	// default), snake_case or keep
+	Naming NamingStrategy `yaml:"naming"`
+
+	// WeaviateVersion is the Weaviate release the schema targets, e.g. "1.25". Features
+	// the release doesn't support are reported as errors. Empty accepts every feature.
+	WeaviateVersion string `yaml:"weaviateVersion"`
+
+	// Lint sets the severity of the rules checked by `weave lint`
+	Lint LintConfig `yaml:"lint"`
+
+	// Cloud configures the generated NewCloudClient
+	Cloud CloudConfig `yaml:"cloud"`
+
+	// State is where `weave apply` saves the applied schema and `weave diff` reads it
+	// back, see OpenState
+	State string `yaml:"state"`
+
+	// Clusters lists the clusters of the deployment, e.g. one per region, which `weave
+	// apply` and `weave diff` run against together with --all-clusters or by --cluster name
+	Clusters []ClusterConfig `yaml:"clusters"`
+
+	// Environments overlays class settings per deployment environment, e.g. dev, staging
+	// and prod, so the same models generate the schema each one needs; see UseEnvironment
+	Environments map[string]EnvironmentConfig `yaml:"environments"`
+
+	// environment is the overlay selected by UseEnvironment
+	environment     *EnvironmentConfig
+	environmentName string
+}
+
+// EnvironmentConfig overrides the generated class settings for an environment: for every
+// class, then for the classes named in Classes
+type EnvironmentConfig struct {
+	ClassOverlay `yaml:",inline"`
+
+	Classes map[string]ClassOverlay `yaml:"classes"`
+}
+
+// ClassOverlay replaces class settings, whether the class's +weave:config: marker and the
+// defaults set them or not
+type ClassOverlay struct {
+	// Vectorizer replaces the class's vectorizer, dropping the moduleConfig of the one it
+	// replaces. Classes with named vectors keep theirs.
+	Vectorizer string `yaml:"vectorizer"`
+
+	// ReplicationFactor sets replicationConfig.factor
+	ReplicationFactor int `yaml:"replicationFactor"`
+
+	// ShardingConfig is merged key by key into shardingConfig
+	ShardingConfig map[string]interface{} `yaml:"shardingConfig"`
+
+	// ModuleConfig is merged key by key into the moduleConfig of each module. At the
+	// environment level it only changes modules a class already uses; under Classes it
+	// also adds them.
+	ModuleConfig map[string]interface{} `yaml:"moduleConfig"`
+}
+
+// UseEnvironment selects the environment whose overlay generation applies
+func (c *Config) UseEnvironment(name string) error {
+	env, ok := c.Environments[name]
+	if !ok {
+		return fmt.Errorf("unknown environment %q (the config defines %s)", name, environmentList(c.Environments))
+	}
+	if env.ReplicationFactor < 0 {
+		return fmt.Errorf("invalid replicationFactor %d of environment %s", env.ReplicationFactor, name)
+	}
+	for class, overlay := range env.Classes {
+		if overlay.ReplicationFactor < 0 {
+			return fmt.Errorf("invalid replicationFactor %d of class %s in environment %s", overlay.ReplicationFactor, class, name)
+		}
+	}
+	c.environment = &env
+	c.environmentName = name
+	return nil
+}
+
+// environmentList formats the names of the environments for a message
+func environmentList(envs map[string]EnvironmentConfig) string {
+	if len(envs) == 0 {
+		return "no environments"
+	}
+	return strings.Join(slices.Sorted(maps.Keys(envs)), ", ")
+}
+
+// ClusterConfig is a Weaviate cluster of the deployment
+type ClusterConfig struct {
+	Name   string `yaml:"name"`
+	Host   string `yaml:"host"`   // e.g. weaviate.eu.example.com:443
+	Scheme string `yaml:"scheme"` // http by default
+
+	// APIKeyEnv names the environment variable holding the cluster's API key, so keys
+	// stay out of the config file
+	APIKeyEnv string `yaml:"apiKeyEnv"`
+}
+
+// Cluster returns the cluster of the config with the given name
+func (c *Config) Cluster(name string) (ClusterConfig, error) {
+	for _, cluster := range c.Clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	names := make([]string, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		names[i] = cluster.Name
+	}
+	if len(names) == 0 {
+		return ClusterConfig{}, fmt.Errorf("unknown cluster %q (the config defines no clusters)", name)
+	}
+	return ClusterConfig{}, fmt.Errorf("unknown cluster %q (the config defines %s)", name, strings.Join(names, ", "))
+}
+
+// CloudConfig configures the client generated for Weaviate Cloud
+type CloudConfig struct {
+	// Headers maps the headers of third-party inference API keys, e.g. X-OpenAI-Api-Key,
+	// to the environment variables NewCloudClient reads them from, so keys stay out of
+	// the generated code
+	Headers map[string]string `yaml:"headers"`
+}
+
+// OutputConfig controls the files written by code generation
+type OutputConfig struct {
+	// Header is a text/template rendered as the comment at the top of every generated
+	// file, with {{.Version}} and {{.SourceHash}} available. Go tooling only treats the
+	// file as generated when a line matches "Code generated ... DO NOT EDIT."
+	Header string `yaml:"header"`
+
+	// BuildTags is an optional build constraint expression, e.g. "!noweave",
+	// emitted as a //go:build line so generated code can be excluded from builds
+	BuildTags string `yaml:"buildTags"`
+
+	// OpenTelemetry generates weave_otel.go with tracing and metrics middleware. It's
+	// opt-in so the OpenTelemetry modules are only required when it's used.
+	OpenTelemetry bool `yaml:"openTelemetry"`
+
+	// Cache generates weave_cache.go with middleware caching reads by ID, in memory or in a
+	// pluggable store
+	Cache bool `yaml:"cache"`
+
+	// Metadata embeds an x-weave block with the weave version, git commit, source hash
+	// and generation time in the schema JSON, and generates weave_metadata.go with the
+	// same values as constants, so deployed schemas can be traced to their sources
+	Metadata bool `yaml:"metadata"`
+
+	// Handlers generates weave_handlers.go with NewHandler, serving the CRUD operations of
+	// every class over HTTP
+	Handlers bool `yaml:"handlers"`
+
+	// TestCode generates weave_helpers_test.go with a testcontainers-go harness
+	// starting Weaviate with the schema, and fixture builders per class
+	TestCode bool `yaml:"testCode"`
+
+	// Embedded generates weave_embedded.go running Weaviate as a local process, without Docker,
+	// and lets the test harness use it
+	Embedded bool `yaml:"embedded"`
+
+	// Benchmarks generates a <class>_bench_test.go per class benchmarking inserts, batch
+	// inserts, reads by ID and vector searches against the Weaviate at $WEAVE_BENCH_HOST
+	Benchmarks bool `yaml:"benchmarks"`
+}
+
+// ClassDefaults are applied to every class that doesn't set the value itself
+type ClassDefaults struct {
+	// ModuleConfig is merged per module: a class only receives the defaults for
+	// modules it doesn't already configure through its +weave:config: marker
+	ModuleConfig map[string]interface{} `yaml:"moduleConfig"`
+
+	// Indexes sets the indexes of properties by data type, e.g. text[] or int, for the
+	// properties whose weave tag doesn't choose them
+	Indexes map[string]IndexDefaults `yaml:"indexes"`
+}
+
+// IndexDefaults are the index settings of the properties of a data type; unset values
+// leave the choice to the server
+type IndexDefaults struct {
+	IndexFilterable *bool `yaml:"indexFilterable"`
+	IndexSearchable *bool `yaml:"indexSearchable"`
+}
+
+// validate checks the defaults can apply to their data types
+func (d ClassDefaults) validate() error {
+	for dataType, indexes := range d.Indexes {
+		if indexes.IndexSearchable != nil && *indexes.IndexSearchable && !isTextType([]string{dataType}) {
+			return fmt.Errorf("invalid index defaults of %s: indexSearchable only applies to text and text[] properties", dataType)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads a weave.yaml config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %v", path, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, cluster := range cfg.Clusters {
+		if cluster.Name == "" || cluster.Host == "" {
+			return nil, fmt.Errorf("cluster %d of config file %s needs a name and a host", i+1, path)
+		}
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("config file %s defines cluster %s twice", path, cluster.Name)
+		}
+		seen[cluster.Name] = true
+	}
+
+	return cfg, nil
+}
+
+// applyDefaults injects the configured defaults into a class
+func (d ClassDefaults) applyDefaults(class *WeaviateClass) {
+	for module, moduleConfig := range d.ModuleConfig {
+		if _, ok := class.ModuleConfig[module]; ok {
+			continue
+		}
+		if class.ModuleConfig == nil {
+			class.ModuleConfig = make(map[string]interface{})
+		}
+		class.ModuleConfig[module] = copyConfigValue(moduleConfig)
+	}
+	d.applyIndexDefaults(class.Properties)
+}
+
+// applyIndexDefaults sets the unset indexes of properties and their nested properties,
+// except for properties setting indexInverted, which Weaviate doesn't accept along with them
+func (d ClassDefaults) applyIndexDefaults(props []WeaviateProperty) {
+	for i := range props {
+		prop := &props[i]
+		if indexes, ok := d.Indexes[strings.Join(prop.DataType, ",")]; ok && prop.IndexInverted == nil {
+			if prop.IndexFilterable == nil {
+				prop.IndexFilterable = indexes.IndexFilterable
+			}
+			if prop.IndexSearchable == nil {
+				prop.IndexSearchable = indexes.IndexSearchable
+			}
+		}
+		d.applyIndexDefaults(prop.NestedProperties)
+	}
+}
+
+// copyConfigValue deep-copies a decoded config value so classes never share nested maps
+func copyConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = copyConfigValue(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = copyConfigValue(val)
+		}
+		return s
+	}
+	return value
+}
+
+// applyEnvironment overlays the selected environment onto the classes, warning about
+// class overlays naming classes that weren't generated
+func (c *Config) applyEnvironment(classes []WeaviateClass, diags *Diagnostics) {
+	env := c.environment
+	if env == nil {
+		return
+	}
+
+	generated := make(map[string]bool, len(classes))
+	for i := range classes {
+		class := &classes[i]
+		generated[class.Class] = true
+		env.ClassOverlay.apply(class, false)
+		if overlay, ok := env.Classes[class.Class]; ok {
+			overlay.apply(class, true)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(env.Classes)) {
+		if !generated[name] {
+			diags.add(SeverityWarning, noPos, "environment %s overlays class %s, which isn't generated", c.environmentName, name)
+		}
+	}
+}
+
+// apply overrides the settings of a class; addModules lets ModuleConfig configure modules
+// the class doesn't use yet
+func (o ClassOverlay) apply(class *WeaviateClass, addModules bool) {
+	if o.Vectorizer != "" && o.Vectorizer != class.Vectorizer && len(class.VectorConfig) == 0 {
+		if _, ok := o.ModuleConfig[class.Vectorizer]; !ok {
+			delete(class.ModuleConfig, class.Vectorizer)
+		}
+		class.Vectorizer = o.Vectorizer
+		class.defaultVectorizer = false
+	}
+
+	if o.ReplicationFactor > 0 {
+		if class.ReplicationConfig == nil {
+			class.ReplicationConfig = make(map[string]interface{})
+		}
+		class.ReplicationConfig["factor"] = o.ReplicationFactor
+	}
+
+	if len(o.ShardingConfig) > 0 {
+		if class.ShardingConfig == nil {
+			class.ShardingConfig = make(map[string]interface{})
+		}
+		for key, value := range o.ShardingConfig {
+			class.ShardingConfig[key] = copyConfigValue(value)
+		}
+	}
+
+	modules := classModules(*class)
+	for module, moduleConfig := range o.ModuleConfig {
+		if !addModules && !slices.Contains(modules, module) {
+			continue
+		}
+		settings, ok := moduleConfig.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if class.ModuleConfig == nil {
+			class.ModuleConfig = make(map[string]interface{})
+		}
+		existing, _ := class.ModuleConfig[module].(map[string]interface{})
+		if existing == nil {
+			existing = make(map[string]interface{})
+			class.ModuleConfig[module] = existing
+		}
+		for key, value := range settings {
+			existing[key] = copyConfigValue(value)
+		}
+	}
+}
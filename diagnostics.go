@@ -0,0 +1,144 @@
+package weave
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// Severity ranks a Diagnostic
+type Severity int
+
+const (
+	// SeverityWarning marks a problem that still produces usable output
+	SeverityWarning Severity = iota
+	// SeverityError marks a problem that makes the affected output invalid or incomplete
+	SeverityError
+)
+
+// String returns the lowercase name of the severity
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// MarshalText implements encoding.TextMarshaler, so severities appear by name in JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic is a problem found while processing Go sources
+type Diagnostic struct {
+	Severity Severity
+	Pos      token.Position
+	Message  string
+
+	// Rule is the lint rule that reported the problem, empty for generation problems
+	Rule string
+
+	// Owner is the team owning the class a lint finding is about, see +weave:owner:
+	Owner string
+}
+
+// String formats the diagnostic as "file:line:col: severity: message", followed by the rule in
+// parentheses and the owner in brackets
+func (d Diagnostic) String() string {
+	msg := d.Message
+	if d.Rule != "" {
+		msg += " (" + d.Rule + ")"
+	}
+	if d.Owner != "" {
+		msg += " [owner: " + d.Owner + "]"
+	}
+	if d.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, msg)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, msg)
+}
+
+// MarshalJSON implements json.Marshaler, writing the position as file, line and column
+// fields left out when it isn't known
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Severity Severity `json:"severity"`
+		Message  string   `json:"message"`
+		File     string   `json:"file,omitempty"`
+		Line     int      `json:"line,omitempty"`
+		Column   int      `json:"column,omitempty"`
+		Rule     string   `json:"rule,omitempty"`
+		Owner    string   `json:"owner,omitempty"`
+	}{d.Severity, d.Message, d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Rule, d.Owner})
+}
+
+// Diagnostics collects every problem found during generation so they can be reported at once
+type Diagnostics []Diagnostic
+
+// HasErrors reports whether any diagnostic is an error
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// HasWarnings reports whether any diagnostic is a warning
+func (d Diagnostics) HasWarnings() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// Strict returns a copy of the diagnostics with every warning promoted to an error
+func (d Diagnostics) Strict() Diagnostics {
+	strict := make(Diagnostics, len(d))
+	for i, diag := range d {
+		diag.Severity = SeverityError
+		strict[i] = diag
+	}
+	return strict
+}
+
+// FilterOwner keeps the diagnostics about classes owned by owner
+func (d Diagnostics) FilterOwner(owner string) Diagnostics {
+	var filtered Diagnostics
+	for _, diag := range d {
+		if diag.Owner == owner {
+			filtered = append(filtered, diag)
+		}
+	}
+	return filtered
+}
+
+// Error implements error, listing one diagnostic per line
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the diagnostics as an error when any of them is an error, nil otherwise
+func (d Diagnostics) Err() error {
+	if d.HasErrors() {
+		return d
+	}
+	return nil
+}
+
+// add records a diagnostic
+func (d *Diagnostics) add(severity Severity, pos token.Position, format string, args ...interface{}) {
+	*d = append(*d, Diagnostic{
+		Severity: severity,
+		Pos:      pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,362 @@
+package weave
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ChangeKind classifies a difference between two schemas
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+	ChangeRenamed ChangeKind = "renamed" // From holds the property's former name
+)
+
+// Change is one difference between two schemas. Property is empty for changes to the class
+// itself, and Setting names the changed setting as a dotted path, e.g. vectorIndexConfig.ef;
+// it's empty when a whole class or property was added or removed.
+type Change struct {
+	Kind     ChangeKind  `json:"kind"`
+	Class    string      `json:"class"`
+	Property string      `json:"property,omitempty"`
+	Setting  string      `json:"setting,omitempty"`
+	From     interface{} `json:"from,omitempty"`
+	To       interface{} `json:"to,omitempty"`
+
+	// Owner is the team owning the class, from the x-weave-owners block of the schemas
+	Owner string `json:"owner,omitempty"`
+
+	// Deprecated is set when a removed property was deprecated in the old schema, so its
+	// removal was planned
+	Deprecated bool `json:"deprecated,omitempty"`
+}
+
+// String formats the change as a line of the text diff, followed by the owner in brackets
+func (c Change) String() string {
+	if owner := c.Owner; owner != "" {
+		c.Owner = ""
+		return c.String() + " [owner: " + owner + "]"
+	}
+
+	target := c.Class
+	if c.Property != "" {
+		target += "." + c.Property
+	}
+
+	switch {
+	case c.Setting == "" && c.Kind == ChangeAdded:
+		return "+ " + target
+	case c.Kind == ChangeRenamed:
+		return fmt.Sprintf("~ %s renamed from %v", target, c.From)
+	case c.Setting == "" && c.Kind == ChangeRemoved && c.Deprecated:
+		return "- " + target + " (deprecated)"
+	case c.Setting == "" && c.Kind == ChangeRemoved:
+		return "- " + target
+	case c.Kind == ChangeAdded:
+		return fmt.Sprintf("~ %s %s: (unset) -> %s", target, c.Setting, formatDiffValue(c.To))
+	case c.Kind == ChangeRemoved:
+		return fmt.Sprintf("~ %s %s: %s -> (unset)", target, c.Setting, formatDiffValue(c.From))
+	}
+	return fmt.Sprintf("~ %s %s: %s -> %s", target, c.Setting, formatDiffValue(c.From), formatDiffValue(c.To))
+}
+
+// SchemaDiff lists the changes turning one schema into another, ordered by class and property
+type SchemaDiff []Change
+
+// DiffSchemaJSON compares two schemas, each a {"classes": [...]} schema as written by
+// weave, an array of classes or a single class. Settings are compared as JSON, so
+// config keys weave doesn't model are compared too. Properties listed in the
+// x-weave-deprecated block of a schema are compared with a deprecated setting, holding
+// the deprecation note or true, and the properties its x-weave-renamed block lists are
+// compared with the properties they were renamed from.
+func DiffSchemaJSON(from, to []byte) (SchemaDiff, error) {
+	fromClasses, err := decodeDiffClasses(from)
+	if err != nil {
+		return nil, fmt.Errorf("error reading first schema: %v", err)
+	}
+	toClasses, err := decodeDiffClasses(to)
+	if err != nil {
+		return nil, fmt.Errorf("error reading second schema: %v", err)
+	}
+	markDeprecated(fromClasses, from)
+	markDeprecated(toClasses, to)
+	renamed := decodeSchemaRenames(to)
+
+	var diff SchemaDiff
+	for _, name := range unionKeys(fromClasses, toClasses) {
+		fromClass, inFrom := fromClasses[name]
+		toClass, inTo := toClasses[name]
+		switch {
+		case !inFrom:
+			diff = append(diff, Change{Kind: ChangeAdded, Class: name})
+		case !inTo:
+			diff = append(diff, Change{Kind: ChangeRemoved, Class: name})
+		default:
+			diff = append(diff, diffClass(name, fromClass, toClass, renamed[name])...)
+		}
+	}
+
+	// Changes go to the owner of the class in the new schema, or in the old one for removed classes
+	owners := decodeSchemaOwners(from)
+	maps.Copy(owners, decodeSchemaOwners(to))
+	for i := range diff {
+		diff[i].Owner = owners[diff[i].Class]
+	}
+	return diff, nil
+}
+
+// decodeSchemaOwners reads the x-weave-owners block of a schema document, if any
+func decodeSchemaOwners(data []byte) map[string]string {
+	var doc struct {
+		Owners map[string]string `json:"x-weave-owners"`
+	}
+	owners := make(map[string]string)
+	if json.Unmarshal(data, &doc) == nil {
+		maps.Copy(owners, doc.Owners)
+	}
+	return owners
+}
+
+// markDeprecated sets the deprecated setting of the properties the x-weave-deprecated
+// block of a schema document lists
+func markDeprecated(classes map[string]map[string]interface{}, data []byte) {
+	var doc struct {
+		Deprecated map[string]map[string]string `json:"x-weave-deprecated"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return
+	}
+	for name, notes := range doc.Deprecated {
+		props := indexProperties(classes[name]["properties"])
+		for prop, note := range notes {
+			if p, ok := props[prop]; ok {
+				var value interface{} = true
+				if note != "" {
+					value = note
+				}
+				p["deprecated"] = value
+			}
+		}
+	}
+}
+
+// decodeSchemaRenames reads the x-weave-renamed block of a schema document, if any
+func decodeSchemaRenames(data []byte) map[string]map[string]string {
+	var doc struct {
+		Renamed map[string]map[string]string `json:"x-weave-renamed"`
+	}
+	json.Unmarshal(data, &doc)
+	return doc.Renamed
+}
+
+// FilterOwner keeps the changes to classes owned by owner
+func (d SchemaDiff) FilterOwner(owner string) SchemaDiff {
+	var filtered SchemaDiff
+	for _, change := range d {
+		if change.Owner == owner {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// decodeDiffClasses indexes the classes of a schema document by name
+func decodeDiffClasses(data []byte) (map[string]map[string]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var items []interface{}
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		if classes, ok := d["classes"]; ok {
+			items, _ = classes.([]interface{})
+		} else {
+			items = []interface{}{d}
+		}
+	case []interface{}:
+		items = d
+	default:
+		return nil, fmt.Errorf("expected a schema, an array of classes or a class")
+	}
+
+	classes := make(map[string]map[string]interface{}, len(items))
+	for i, item := range items {
+		class, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("class %d isn't an object", i)
+		}
+		name, _ := class["class"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("class %d has no name", i)
+		}
+		classes[name] = class
+	}
+	return classes, nil
+}
+
+// diffClass compares the settings and properties of a class present in both schemas;
+// renamed maps its renamed properties to their former names
+func diffClass(name string, from, to map[string]interface{}, renamed map[string]string) SchemaDiff {
+	diff := diffSettings(Change{Class: name}, "", withoutKeys(from, "class", "properties"), withoutKeys(to, "class", "properties"))
+	return append(diff, diffProperties(name, "", from["properties"], to["properties"], renamed)...)
+}
+
+// diffProperties compares property lists, recursing into nested properties with
+// their names prefixed by the parent's. A property renamed from one only the old list
+// has is compared with it, rather than reported as removed and added.
+func diffProperties(class, prefix string, from, to interface{}, renamed map[string]string) SchemaDiff {
+	fromProps, toProps := indexProperties(from), indexProperties(to)
+
+	formerNames := make(map[string]string) // former names of the renames to follow
+	isFormer := make(map[string]bool)
+	for name, former := range renamed {
+		_, fromHasFormer := fromProps[former]
+		_, fromHasName := fromProps[name]
+		_, toHasName := toProps[name]
+		_, toHasFormer := toProps[former]
+		if fromHasFormer && !fromHasName && toHasName && !toHasFormer {
+			formerNames[name] = former
+			isFormer[former] = true
+		}
+	}
+
+	var diff SchemaDiff
+	for _, name := range unionKeys(fromProps, toProps) {
+		if isFormer[name] {
+			continue
+		}
+		fromProp, inFrom := fromProps[name]
+		toProp, inTo := toProps[name]
+		if former, ok := formerNames[name]; ok {
+			fromProp, inFrom = fromProps[former], true
+			diff = append(diff, Change{Kind: ChangeRenamed, Class: class, Property: prefix + name, From: former})
+		}
+		switch {
+		case !inFrom:
+			diff = append(diff, Change{Kind: ChangeAdded, Class: class, Property: prefix + name})
+		case !inTo:
+			diff = append(diff, Change{Kind: ChangeRemoved, Class: class, Property: prefix + name, Deprecated: fromProp["deprecated"] != nil})
+		default:
+			base := Change{Class: class, Property: prefix + name}
+			fromSettings, toSettings := withoutKeys(fromProp, "name", "nestedProperties"), withoutKeys(toProp, "name", "nestedProperties")
+			withServerIndexes(fromSettings)
+			withServerIndexes(toSettings)
+			diff = append(diff, diffSettings(base, "", fromSettings, toSettings)...)
+			diff = append(diff, diffProperties(class, prefix+name+".", fromProp["nestedProperties"], toProp["nestedProperties"], nil)...)
+		}
+	}
+	return diff
+}
+
+// withServerIndexes fills in the indexes a property leaves unset with the ones Weaviate
+// creates for its data type, so a schema leaving them to the server matches the schema
+// read back from it. A property setting the older indexInverted is left as it is.
+func withServerIndexes(prop map[string]interface{}) {
+	if prop["indexInverted"] != nil {
+		return
+	}
+	var dataType []string
+	if types, ok := prop["dataType"].([]interface{}); ok {
+		for _, t := range types {
+			s, _ := t.(string)
+			dataType = append(dataType, s)
+		}
+	}
+	if _, ok := prop["indexFilterable"]; !ok {
+		prop["indexFilterable"] = strings.Join(dataType, ",") != "blob"
+	}
+	if _, ok := prop["indexSearchable"]; !ok {
+		prop["indexSearchable"] = isTextType(dataType)
+	}
+}
+
+// indexProperties indexes a properties array by property name
+func indexProperties(value interface{}) map[string]map[string]interface{} {
+	items, _ := value.([]interface{})
+	props := make(map[string]map[string]interface{}, len(items))
+	for _, item := range items {
+		if prop, ok := item.(map[string]interface{}); ok {
+			if name, ok := prop["name"].(string); ok {
+				props[name] = prop
+			}
+		}
+	}
+	return props
+}
+
+// diffSettings compares two settings objects key by key, recursing into nested objects.
+// Arrays and other values are compared as a whole.
+func diffSettings(base Change, path string, from, to map[string]interface{}) SchemaDiff {
+	var diff SchemaDiff
+	for _, key := range unionKeys(from, to) {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		setting := path + key
+
+		fromMap, fromIsMap := fromValue.(map[string]interface{})
+		toMap, toIsMap := toValue.(map[string]interface{})
+		if fromIsMap && toIsMap {
+			diff = append(diff, diffSettings(base, setting+".", fromMap, toMap)...)
+			continue
+		}
+
+		change := base
+		change.Setting = setting
+		switch {
+		case !inFrom:
+			change.Kind, change.To = ChangeAdded, toValue
+		case !inTo:
+			change.Kind, change.From = ChangeRemoved, fromValue
+		case !reflect.DeepEqual(fromValue, toValue):
+			change.Kind, change.From, change.To = ChangeChanged, fromValue, toValue
+		default:
+			continue
+		}
+		diff = append(diff, change)
+	}
+	return diff
+}
+
+// withoutKeys returns a copy of m without the given keys
+func withoutKeys(m map[string]interface{}, keys ...string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if !slices.Contains(keys, key) {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// formatDiffValue formats a setting value as compact JSON
+func formatDiffValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,70 @@
+package weave
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// reference is a property of one class pointing at another class
+type reference struct {
+	From     string
+	Property string
+	To       string
+}
+
+// references lists the cross-references of the schema in class and property order
+func (s *WeaviateSchemaDefinition) references() []reference {
+	var refs []reference
+	for _, class := range s.Classes {
+		for _, prop := range class.Properties {
+			for _, dataType := range prop.DataType {
+				if isReferenceType(dataType) {
+					refs = append(refs, reference{From: class.Class, Property: prop.Name, To: dataType})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// WriteMarkdown renders the schema as Markdown documentation: a table of the
+// properties of every class followed by the references between classes, with an
+// optional Mermaid ER diagram of the classes.
+func WriteMarkdown(w io.Writer, schema *WeaviateSchemaDefinition, mermaid bool) error {
+	t, err := templates.ReadFile("templates/docs.tmpl")
+	if err != nil {
+		return fmt.Errorf("error reading docs template: %v", err)
+	}
+	tmpl, err := template.New("docs").Funcs(template.FuncMap{
+		"cell":    markdownCell,
+		"anchor":  strings.ToLower,
+		"isClass": isReferenceType,
+	}).Parse(string(t))
+	if err != nil {
+		return fmt.Errorf("error parsing docs template: %v", err)
+	}
+
+	err = tmpl.Execute(w, struct {
+		Classes    []WeaviateClass
+		References []reference
+		Modules    []ModuleUse
+		Mermaid    bool
+	}{
+		Classes:    schema.Classes,
+		References: schema.references(),
+		Modules:    schema.Modules(),
+		Mermaid:    mermaid,
+	})
+	if err != nil {
+		return fmt.Errorf("error executing docs template: %v", err)
+	}
+	return nil
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,86 @@
+package weave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ClusterDrift compares the schema on a cluster with a schema document as DiffSchemaJSON
+// reads it, listing the changes from the cluster to the document. Only the classes and
+// settings the document declares are compared, so the defaults Weaviate fills in and the
+// classes of other applications aren't reported; properties a class only has on the
+// cluster are.
+func ClusterDrift(ctx context.Context, client *ClusterClient, schemaJSON []byte) (SchemaDiff, error) {
+	var live json.RawMessage
+	if err := client.do(ctx, http.MethodGet, "/schema", nil, nil, &live); err != nil {
+		return nil, fmt.Errorf("error reading schema: %v", err)
+	}
+	liveClasses, err := decodeDiffClasses(live)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cluster schema: %v", err)
+	}
+	declared, err := decodeDiffClasses(schemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema: %v", err)
+	}
+
+	var classes []interface{}
+	for name, class := range liveClasses {
+		if want, ok := declared[name]; ok {
+			classes = append(classes, declaredSettings(class, want))
+		}
+	}
+	from, err := json.Marshal(map[string]interface{}{"classes": classes})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling cluster schema: %v", err)
+	}
+	return DiffSchemaJSON(from, schemaJSON)
+}
+
+// declaredSettings keeps the settings of a class or property read from a cluster that
+// declared sets too, recursing into nested objects and into the properties both have
+func declaredSettings(live, declared map[string]interface{}) map[string]interface{} {
+	kept := make(map[string]interface{}, len(declared))
+	for key, value := range live {
+		want, ok := declared[key]
+		switch {
+		case key == "class" || key == "name":
+			kept[key] = value
+		case key == "properties" || key == "nestedProperties":
+			kept[key] = declaredProperties(value, want)
+		case !ok:
+		default:
+			liveMap, isMap := value.(map[string]interface{})
+			wantMap, wantsMap := want.(map[string]interface{})
+			if isMap && wantsMap {
+				kept[key] = declaredSettings(liveMap, wantMap)
+			} else {
+				kept[key] = value
+			}
+		}
+	}
+	return kept
+}
+
+// declaredProperties prunes the settings of the live properties declared has too, and
+// keeps the others whole
+func declaredProperties(live, declared interface{}) []interface{} {
+	items, _ := live.([]interface{})
+	wanted := indexProperties(declared)
+	props := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		prop, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := prop["name"].(string)
+		if want, ok := wanted[name]; ok {
+			props = append(props, declaredSettings(prop, want))
+		} else {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
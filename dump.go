@@ -0,0 +1,408 @@
+package weave
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dump defaults
+const (
+	DefaultDumpPageSize  = 500
+	DefaultDumpShardSize = 10000
+	DefaultRestoreBatch  = 100
+)
+
+// dumpSchemaFile holds the schema a dump was taken with, next to the class directories
+const dumpSchemaFile = "schema.json"
+
+// DumpObject is one line of a dump shard. Properties and vectors are kept as the
+// cluster returned them, so a restore writes back exactly what was dumped.
+type DumpObject struct {
+	Class      string          `json:"class"`
+	ID         string          `json:"id"`
+	Tenant     string          `json:"tenant,omitempty"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+	Vector     json.RawMessage `json:"vector,omitempty"`
+	Vectors    json.RawMessage `json:"vectors,omitempty"`
+}
+
+// DumpOptions controls how objects are read and sharded
+type DumpOptions struct {
+	PageSize  int // objects per cursor request
+	ShardSize int // objects per NDJSON shard file
+}
+
+// Dump exports every object of the schema's classes, with vectors, to NDJSON shards
+// under dir: <dir>/<Class>/<shard>.ndjson, or <dir>/<Class>/<tenant>/<shard>.ndjson
+// for multi-tenant classes. The schema is written to <dir>/schema.json. It returns
+// the number of objects dumped per class.
+func Dump(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, dir string, opts DumpOptions) (map[string]int, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultDumpPageSize
+	}
+	if opts.ShardSize <= 0 {
+		opts.ShardSize = DefaultDumpShardSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating dump directory: %v", err)
+	}
+	schemaJSON, err := schema.ToJSON(true)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling schema: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dumpSchemaFile), schemaJSON, 0644); err != nil {
+		return nil, fmt.Errorf("error writing schema: %v", err)
+	}
+
+	counts := make(map[string]int, len(schema.Classes))
+	for _, class := range schema.Classes {
+		tenants := []string{""}
+		if class.MultiTenancyConfig["enabled"] == true {
+			if tenants, err = client.tenants(ctx, class.Class); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, tenant := range tenants {
+			classDir := filepath.Join(dir, class.Class, tenant)
+			n, err := dumpClass(ctx, client, class.Class, tenant, classDir, opts)
+			if err != nil {
+				return nil, err
+			}
+			counts[class.Class] += n
+		}
+	}
+	return counts, nil
+}
+
+// dumpClass pages through one class (and tenant) with the objects cursor
+func dumpClass(ctx context.Context, client *ClusterClient, className, tenant, dir string, opts DumpOptions) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("error creating dump directory: %v", err)
+	}
+
+	w := &shardWriter{dir: dir, size: opts.ShardSize}
+	defer w.close()
+
+	after := ""
+	for {
+		query := url.Values{
+			"class":   {className},
+			"limit":   {strconv.Itoa(opts.PageSize)},
+			"include": {"vector"},
+		}
+		if after != "" {
+			query.Set("after", after)
+		}
+		if tenant != "" {
+			query.Set("tenant", tenant)
+		}
+
+		var page struct {
+			Objects []DumpObject `json:"objects"`
+		}
+		if err := client.do(ctx, http.MethodGet, "/objects", query, nil, &page); err != nil {
+			return w.count, fmt.Errorf("error reading %s objects: %v", className, err)
+		}
+		if len(page.Objects) == 0 {
+			break
+		}
+
+		for _, obj := range page.Objects {
+			if err := w.write(obj); err != nil {
+				return w.count, err
+			}
+		}
+		after = page.Objects[len(page.Objects)-1].ID
+	}
+
+	return w.count, w.close()
+}
+
+// shardWriter writes objects to numbered NDJSON files of at most size objects
+type shardWriter struct {
+	dir   string
+	size  int
+	count int
+	file  *os.File
+	buf   *bufio.Writer
+}
+
+func (w *shardWriter) write(obj DumpObject) error {
+	if w.file == nil || w.count%w.size == 0 {
+		if err := w.close(); err != nil {
+			return err
+		}
+		path := filepath.Join(w.dir, fmt.Sprintf("%05d.ndjson", w.count/w.size))
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("error creating shard: %v", err)
+		}
+		w.file, w.buf = f, bufio.NewWriter(f)
+	}
+
+	line, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("error marshaling object %s: %v", obj.ID, err)
+	}
+	w.buf.Write(line)
+	if err := w.buf.WriteByte('\n'); err != nil {
+		return fmt.Errorf("error writing shard: %v", err)
+	}
+	w.count++
+	return nil
+}
+
+func (w *shardWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	f := w.file
+	w.file = nil
+	if err := w.buf.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing shard: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error closing shard: %v", err)
+	}
+	return nil
+}
+
+// Restore imports a dump written by Dump with the batch API, batchSize objects per
+// request. The classes must already exist on the cluster; missing tenants of
+// multi-tenant classes are created. It returns the number of objects restored per class.
+func Restore(ctx context.Context, client *ClusterClient, dir string, batchSize int) (map[string]int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultRestoreBatch
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dump directory: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		className := entry.Name()
+
+		tenants, err := client.classTenants(ctx, className)
+		if err != nil {
+			return nil, err
+		}
+
+		shards, err := shardFiles(filepath.Join(dir, className))
+		if err != nil {
+			return nil, err
+		}
+
+		r := &restorer{client: client, className: className, tenants: tenants, size: batchSize}
+		for _, shard := range shards {
+			if err := r.restoreShard(ctx, shard); err != nil {
+				return nil, err
+			}
+		}
+		if err := r.flush(ctx); err != nil {
+			return nil, err
+		}
+		counts[className] = r.count
+	}
+	return counts, nil
+}
+
+// shardFiles lists the NDJSON shards under a class directory, including tenant directories
+func shardFiles(dir string) ([]string, error) {
+	var shards []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".ndjson") {
+			shards = append(shards, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading dump directory: %v", err)
+	}
+	slices.Sort(shards)
+	return shards, nil
+}
+
+// restorer batches the objects of one class
+type restorer struct {
+	client    *ClusterClient
+	className string
+	tenants   map[string]bool // nil for classes without multi-tenancy
+	size      int
+	batch     []DumpObject
+	count     int
+}
+
+func (r *restorer) restoreShard(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening shard: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// Vectors make for long lines
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var obj DumpObject
+		if err := json.Unmarshal(scanner.Bytes(), &obj); err != nil {
+			return fmt.Errorf("%s:%d: error parsing object: %v", path, line, err)
+		}
+		if obj.Class != r.className {
+			return fmt.Errorf("%s:%d: object of class %s in the %s directory", path, line, obj.Class, r.className)
+		}
+
+		r.batch = append(r.batch, obj)
+		if len(r.batch) >= r.size {
+			if err := r.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading shard %s: %v", path, err)
+	}
+	return nil
+}
+
+// flush creates missing tenants and sends the pending batch
+func (r *restorer) flush(ctx context.Context) error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, obj := range r.batch {
+		if obj.Tenant != "" && r.tenants != nil && !r.tenants[obj.Tenant] {
+			r.tenants[obj.Tenant] = true
+			missing = append(missing, obj.Tenant)
+		}
+	}
+	if len(missing) > 0 {
+		if err := r.client.addTenants(ctx, r.className, missing); err != nil {
+			return err
+		}
+	}
+
+	if err := r.client.batchObjects(ctx, r.batch); err != nil {
+		return fmt.Errorf("error restoring %s objects: %v", r.className, err)
+	}
+	r.count += len(r.batch)
+	r.batch = r.batch[:0]
+	return nil
+}
+
+// batchObjects writes objects with the batch API, failing when any object was rejected
+func (c *ClusterClient) batchObjects(ctx context.Context, objects []DumpObject) error {
+	body := struct {
+		Objects []DumpObject `json:"objects"`
+	}{objects}
+
+	var results []struct {
+		ID     string `json:"id"`
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/batch/objects", nil, body, &results); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, res := range results {
+		if res.Result.Errors == nil {
+			continue
+		}
+		for _, e := range res.Result.Errors.Error {
+			failed = append(failed, fmt.Sprintf("%s: %s", res.ID, e.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d objects rejected, first: %s", len(failed), failed[0])
+	}
+	return nil
+}
+
+// classTenants returns the tenants of a multi-tenant class, or nil when multi-tenancy
+// is off. It fails when the class doesn't exist on the cluster.
+func (c *ClusterClient) classTenants(ctx context.Context, className string) (map[string]bool, error) {
+	var class struct {
+		MultiTenancyConfig struct {
+			Enabled bool `json:"enabled"`
+		} `json:"multiTenancyConfig"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/schema/"+url.PathEscape(className), nil, nil, &class); err != nil {
+		return nil, fmt.Errorf("error reading class %s, apply the schema before restoring: %v", className, err)
+	}
+	if !class.MultiTenancyConfig.Enabled {
+		return nil, nil
+	}
+
+	names, err := c.tenants(ctx, className)
+	if err != nil {
+		return nil, err
+	}
+	tenants := make(map[string]bool, len(names))
+	for _, name := range names {
+		tenants[name] = true
+	}
+	return tenants, nil
+}
+
+// tenants lists the tenant names of a class
+func (c *ClusterClient) tenants(ctx context.Context, className string) ([]string, error) {
+	var tenants []struct {
+		Name string `json:"name"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/schema/"+url.PathEscape(className)+"/tenants", nil, nil, &tenants); err != nil {
+		return nil, fmt.Errorf("error listing tenants of %s: %v", className, err)
+	}
+
+	names := make([]string, len(tenants))
+	for i, tenant := range tenants {
+		names[i] = tenant.Name
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// addTenants creates tenants of a class
+func (c *ClusterClient) addTenants(ctx context.Context, className string, names []string) error {
+	tenants := make([]map[string]string, len(names))
+	for i, name := range names {
+		tenants[i] = map[string]string{"name": name}
+	}
+	if err := c.do(ctx, http.MethodPost, "/schema/"+url.PathEscape(className)+"/tenants", nil, tenants, nil); err != nil {
+		return fmt.Errorf("error creating tenants of %s: %v", className, err)
+	}
+	return nil
+}
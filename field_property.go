@@ -0,0 +1,217 @@
+package weave
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// propertyField describes a struct field on its way to becoming a property, the same way
+// whether processStruct read it from source or ClassForType through reflection, so both
+// apply the json and weave tags with convertField
+type propertyField struct {
+	structName string
+	fieldName  string
+	goType     string            // Go type expression of the field
+	json       jsonTag           // the field's json tag
+	options    map[string]string // weave tag options, with those of +weave:prop: markers filled in
+	isMap      bool              // a map, or a pointer to one
+	direct     bool              // holds its value itself, not through a pointer or Optional[T]
+	enum       string            // string enum the field holds, or ""
+	enumSlice  bool              // holds a slice of the enum
+
+	// dataType maps the Go type to Weaviate data types, unless the options choose them
+	dataType func() ([]string, error)
+}
+
+// fieldKind is what a field becomes
+type fieldKind int
+
+const (
+	fieldSkipped     fieldKind = iota // left out of the schema, for auto-schema to add
+	fieldProperty                     // a property of the class
+	fieldPassthrough                  // a map passed through as an object with type=object
+)
+
+// convertField converts a field into a property by its json and weave tags. Warnings go
+// to warn; version holds the Go field of the class's version property, if any yet.
+// Nested properties are left to the caller, which knows the fields of the nested struct.
+func convertField(f propertyField, naming NamingStrategy, version *string, warn func(format string, args ...interface{})) (WeaviateProperty, fieldKind, error) {
+	structName, fieldName, config := f.structName, f.fieldName, f.options
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		if !slices.Contains(propertyTagKeys, key) && !strings.HasPrefix(key, "moduleConfig.") {
+			warn("unknown weave option %q on field %s.%s is ignored%s", key, structName, fieldName, didYouMean(key, propertyTagKeys))
+		}
+	}
+
+	// Use JSON name if available, otherwise derive it from the field name
+	propName := f.json.Name
+	if propName == "" {
+		propName = naming.propertyName(fieldName)
+	}
+	if !propertyNamePattern.MatchString(propName) {
+		return WeaviateProperty{}, fieldSkipped, fmt.Errorf("field %s.%s maps to property %q, which doesn't match Weaviate's property name pattern %s", structName, fieldName, propName, propertyNamePattern)
+	}
+
+	var dataType []string
+	typeOverride := false
+	if dt, ok := config["type"]; ok {
+		dataType = []string{dt}
+		typeOverride = true
+	}
+
+	// The json flag stores any value as JSON text
+	_, jsonText := config["json"]
+	if jsonText {
+		if typeOverride && dataType[0] != "text" {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("field %s.%s is stored as JSON text, so it can't have type %s", structName, fieldName, dataType[0])
+		}
+		dataType = []string{"text"}
+		typeOverride = true
+	}
+
+	// String enums are stored as keywords: text matched as a whole value
+	if f.enum != "" && dataType == nil {
+		dataType = []string{"text"}
+		if f.enumSlice {
+			dataType = []string{"text[]"}
+		}
+		if _, ok := config["tokenization"]; !ok {
+			if config == nil {
+				config = make(map[string]string)
+			}
+			config["tokenization"] = "field"
+		}
+	}
+
+	// Determine the data type
+	if dataType == nil {
+		d, err := f.dataType()
+		if err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("can't determine data type for field %s.%s: %v", structName, fieldName, err)
+		}
+		dataType = d
+
+		// The string option makes encoding/json quote numbers and booleans
+		if f.json.String && len(dataType) == 1 && slices.Contains([]string{"int", "number", "boolean"}, dataType[0]) {
+			warn("field %s.%s is encoded as a JSON string by its json tag, so it's stored as text instead of %s", structName, fieldName, dataType[0])
+			dataType = []string{"text"}
+		}
+	}
+
+	property := WeaviateProperty{
+		Name:        propName,
+		JSONKey:     jsonKey(f.json, fieldName, propName),
+		DataType:    dataType,
+		JSON:        jsonText,
+		Description: config["description"],
+		GoField:     fieldName,
+		GoType:      f.goType,
+		Enum:        f.enum,
+	}
+
+	// Maps have dynamic keys, which can't be declared as nested properties. They're
+	// stored as JSON text with type=text, and otherwise left to auto-schema; type=object
+	// declares that on purpose, for semi-structured values passed through as they are.
+	if f.isMap {
+		switch {
+		case !typeOverride:
+			warn("field %s.%s is a map with dynamic keys, which Weaviate can't declare as nested properties; it's left out of the schema for auto-schema to add on import. Tag it weave:\"type=text\" to store it as JSON text, or weave:\"type=object\" to pass it through", structName, fieldName)
+			return WeaviateProperty{}, fieldSkipped, nil
+		case dataType[0] == "text":
+			property.JSON = true
+		case dataType[0] == "object":
+			if _, ok := config["deprecated"]; ok {
+				return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: deprecated doesn't apply to maps passed through with type=object, which auto-schema adds", structName, fieldName)
+			}
+			return WeaviateProperty{Name: propName, DataType: dataType, GoField: fieldName, GoType: f.goType, JSONKey: property.JSONKey}, fieldPassthrough, nil
+		}
+	}
+
+	if tokenization, ok := config["tokenization"]; ok {
+		if err := validateTokenization(tokenization, dataType); err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+		property.Tokenization = tokenization
+	}
+
+	var err error
+	if val, ok := config["indexFilterable"]; ok {
+		if property.IndexFilterable, err = boolOption("indexFilterable", val); err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+	}
+
+	if val, ok := config["indexSearchable"]; ok {
+		if property.IndexSearchable, err = boolOption("indexSearchable", val); err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+		if *property.IndexSearchable && !isTextType(dataType) {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: indexSearchable only applies to text and text[] properties, not %s", structName, fieldName, strings.Join(dataType, ","))
+		}
+	}
+
+	if val, ok := config["indexInverted"]; ok {
+		if property.IndexFilterable != nil || property.IndexSearchable != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: indexInverted can't be set along with indexFilterable or indexSearchable, which replace it", structName, fieldName)
+		}
+		if property.IndexInverted, err = boolOption("indexInverted", val); err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+	}
+
+	moduleConfig, err := propertyModuleConfig(config)
+	if err != nil {
+		return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+	}
+	property.ModuleConfig = moduleConfig
+
+	if val, ok := config["indexRangeFilters"]; ok {
+		if !slices.Contains(rangeFilterTypes, strings.Join(dataType, ",")) {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: indexRangeFilters only applies to int, number or date properties, not %s", structName, fieldName, strings.Join(dataType, ","))
+		}
+		rangeFilters, err := boolOption("indexRangeFilters", val)
+		if err != nil {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+		property.IndexRangeFilters = *rangeFilters
+	}
+
+	if from, ok := config["renamedFrom"]; ok {
+		if !propertyNamePattern.MatchString(from) || from == propName {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: renamedFrom=%s isn't a former property name", structName, fieldName, from)
+		}
+		property.RenamedFrom = from
+	}
+
+	if val, ok := config["deprecated"]; ok && val != "false" {
+		if _, ok := config["version"]; ok {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: the version property can't be deprecated, as Update writes it", structName, fieldName)
+		}
+		property.Deprecated = true
+		property.DeprecationNote = deprecationNote(val)
+	}
+
+	// The version counter is compared and incremented as a Go integer
+	if _, ok := config["version"]; ok {
+		if strings.Join(dataType, ",") != "int" || !f.direct {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: version only applies to integer fields, not %s", structName, fieldName, f.goType)
+		}
+		if *version != "" {
+			return WeaviateProperty{}, fieldSkipped, fmt.Errorf("invalid weave tag on field %s.%s: %s already holds the version of %s", structName, fieldName, *version, structName)
+		}
+		*version = fieldName
+		property.Version = true
+	}
+
+	return property, fieldProperty, nil
+}
+
+// jsonKey returns the key encoding/json writes a field as, or "" when it's the property's name
+func jsonKey(tag jsonTag, fieldName, propName string) string {
+	if tag.Name != "" || fieldName == propName {
+		return ""
+	}
+	return fieldName
+}
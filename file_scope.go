@@ -0,0 +1,106 @@
+package weave
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fileScope carries the package and file level declarations needed to resolve field types
+type fileScope struct {
+	fset    *token.FileSet
+	imports map[string]string // import name -> import path
+	enums   map[string]*Enum
+	types   TypeRegistry
+	naming  NamingStrategy
+	diags   *Diagnostics
+
+	// structs declared anywhere in the package and the types marked as classes,
+	// shared by every file
+	structs map[string]*structDecl
+	classes map[string]bool
+
+	// nesting holds the structs being converted to nested properties, to catch cycles
+	nesting map[*ast.StructType]bool
+}
+
+// newFileScope indexes the imports of a file
+func newFileScope(file *ast.File, fset *token.FileSet, enums map[string]*Enum, types TypeRegistry, diags *Diagnostics) *fileScope {
+	scope := &fileScope{
+		fset:    fset,
+		imports: make(map[string]string),
+		enums:   enums,
+		types:   types,
+		diags:   diags,
+	}
+
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		scope.imports[name] = importPath
+	}
+
+	return scope
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName guesses the package name of an import path like goimports does: the last
+// element, skipping "/vN" major version elements, gopkg.in style ".vN" suffixes and a
+// "go-" prefix, up to the first character that can't be in an identifier, so
+// testcontainers-go is testcontainers
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool { return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) }); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// lookupType resolves a registered type, checking the user registry before the built-ins
+func (s *fileScope) lookupType(goType string) (string, bool) {
+	if dataType, ok := s.types.Lookup(goType); ok {
+		return dataType, true
+	}
+	return builtinTypes.Lookup(goType)
+}
+
+// qualifiedName expands a selector like decimal.Decimal to its import path form
+func (s *fileScope) qualifiedName(sel *ast.SelectorExpr) (string, bool) {
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	importPath, ok := s.imports[ident.Name]
+	if !ok {
+		return "", false
+	}
+	return importPath + "." + sel.Sel.Name, true
+}
+
+// errorf records an error diagnostic at pos
+func (s *fileScope) errorf(pos token.Pos, format string, args ...interface{}) {
+	s.diags.add(SeverityError, s.fset.Position(pos), format, args...)
+}
+
+// warnf records a warning diagnostic at pos
+func (s *fileScope) warnf(pos token.Pos, format string, args ...interface{}) {
+	s.diags.add(SeverityWarning, s.fset.Position(pos), format, args...)
+}
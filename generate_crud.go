@@ -3,13 +3,25 @@ package weave
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"maps"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 )
 
 //go:embed templates
@@ -25,6 +37,9 @@ type TemplateData[T any] struct {
 const (
 	// WeaviatePackage is the package name for the Weaviate client
 	WeaviatePackage = "github.com/weaviate/weaviate-go-client/v5"
+
+	// DefaultHeader is the generated-file comment used when OutputConfig.Header is empty
+	DefaultHeader = "Code generated by weave. DO NOT EDIT."
 )
 
 func findPackageName(schema WeaviateSchemaDefinition, outputDir string) string {
@@ -41,23 +56,125 @@ func findPackageName(schema WeaviateSchemaDefinition, outputDir string) string {
 	return path.Base(outputDir)
 }
 
-// GenerateCRUDCode generates CRUD implementation for all Weaviate classes
+// GenerateCRUDCode generates CRUD implementation for all Weaviate classes,
+// one <class>_crud.go file per class next to client.go and the shared weave_*.go
+// files. Every file is gofmt'd and has its unused imports removed before it's
+// written, and existing files are only replaced when they're generated code.
 // returns the generated package name
 func GenerateCRUDCode(schema *WeaviateSchemaDefinition, outputDir string) (string, error) {
+	return GenerateCRUDCodeWithConfig(schema, outputDir, &Config{})
+}
+
+// GenerateCRUDCodeWithConfig is GenerateCRUDCode with the output settings from cfg
+func GenerateCRUDCodeWithConfig(schema *WeaviateSchemaDefinition, outputDir string, cfg *Config) (string, error) {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", fmt.Errorf("error creating output directory: %v", err)
 	}
 
+	notice, err := cfg.Output.notice(schema.SourceHash)
+	if err != nil {
+		return "", err
+	}
+
 	packageName := findPackageName(*schema, outputDir)
 	// Generate client code
-	if err := generateClientCode(packageName, outputDir); err != nil {
+	if err := generateClientCode(packageName, notice, outputDir); err != nil {
 		return packageName, err
 	}
 
+	// Generate the bulk import, export and delete pipelines, search options, blob encoding,
+	// error types, middleware and limits shared by all classes
+	for _, shared := range []string{"properties", "importer", "export", "delete", "search", "filters", "blobs", "raw", "errors", "middleware", "limits", "credentials"} {
+		if err := generateSharedCode(shared, packageName, notice, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the backup helpers, scoped to the generated classes
+	if err := generateBackupCode(packageName, notice, schema, outputDir); err != nil {
+		return packageName, err
+	}
+
+	// Generate VerifySchema, comparing the live classes with the generated code
+	if err := generateVerifyCode(packageName, notice, schema, outputDir); err != nil {
+		return packageName, err
+	}
+
+	// Generate the registry of the backfills declared with +weave:backfill:
+	if err := generateBackfillCode(packageName, notice, schema.Backfills, outputDir); err != nil {
+		return packageName, err
+	}
+
+	// Generate the Weaviate Cloud constructor
+	if err := generateCloudCode(packageName, notice, cfg.Cloud, outputDir); err != nil {
+		return packageName, err
+	}
+
+	// Generate the optional OpenTelemetry middleware
+	if cfg.Output.OpenTelemetry {
+		if err := generateSharedCode("otel", packageName, notice, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the optional caching middleware
+	if cfg.Output.Cache {
+		if err := generateSharedCode("cache", packageName, notice, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate random data constructors
+	if err := generateFakes(packageName, notice, schema, outputDir); err != nil {
+		return packageName, err
+	}
+
+	// Generate the schema metadata constants
+	if schema.Metadata != nil {
+		if err := generateMetadataCode(packageName, notice, schema.Metadata, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the optional HTTP handlers
+	if cfg.Output.Handlers {
+		if err := generateHandlers(packageName, notice, schema, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the optional Weaviate Embedded runner
+	if cfg.Output.Embedded {
+		if err := generateEmbeddedCode(packageName, notice, cfg, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the optional integration test harness
+	if cfg.Output.TestCode {
+		if err := generateTestCode(packageName, notice, schema, cfg, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate the optional benchmarks
+	if cfg.Output.Benchmarks {
+		if err := generateBenchCode(packageName, notice, schema, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
+	// Generate validation helpers for string enums
+	if len(schema.Enums) > 0 {
+		if err := generateEnumCode(packageName, notice, schema.Enums, outputDir); err != nil {
+			return packageName, err
+		}
+	}
+
 	// Generate CRUD implementation for each class
 	for _, class := range schema.Classes {
-		if err := generateClassCRUD(packageName, class, outputDir); err != nil {
+		if err := generateClassCRUD(packageName, notice, class, schema, outputDir); err != nil {
 			return packageName, fmt.Errorf("error generating CRUD for class %s: %v", class.Class, err)
 		}
 	}
@@ -75,9 +192,9 @@ func generateFromTemplate[T any](src string, data TemplateData[T], filename stri
 		return fmt.Errorf("error parsing %s template: %v", src, err)
 	}
 
-	fmt.Println("Generating", filename, tmpl)
-
-	data.AutogeneratedNotice = "// Code generated by weave. DO NOT EDIT.\n\n"
+	if data.AutogeneratedNotice == "" {
+		data.AutogeneratedNotice = "// " + DefaultHeader + "\n"
+	}
 
 	// Execute the template
 	var buf bytes.Buffer
@@ -85,90 +202,815 @@ func generateFromTemplate[T any](src string, data TemplateData[T], filename stri
 		return fmt.Errorf("error executing class CRUD template: %v", err)
 	}
 
-	// Format the code
+	// Format the code, dropping the imports the template didn't end up using
 	formattedCode, err := format.Source(buf.Bytes())
 	if err != nil {
-		fmt.Println(buf.String())
-		return fmt.Errorf("error formatting generated code: %v", err)
+		return fmt.Errorf("error formatting generated %s: %v", filename, sourceContext(buf.Bytes(), err))
+	}
+	if formattedCode, err = pruneImports(formattedCode); err != nil {
+		return fmt.Errorf("error pruning imports of generated %s: %v", filename, err)
 	}
-	// formattedCode := buf.Bytes()
 
-	// Write the code to file
+	// Write the code to file, unless that replaces code the user wrote
+	if err := checkOverwrite(filename, data.AutogeneratedNotice); err != nil {
+		return err
+	}
 	if err := os.WriteFile(filename, formattedCode, 0644); err != nil {
 		return fmt.Errorf("error writing %s code: %v", src, err)
 	}
 	return nil
 }
 
+// checkOverwrite returns an error when filename exists and isn't generated code, so
+// generation never replaces a file the user wrote
+func checkOverwrite(filename, notice string) error {
+	src, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", filename, err)
+	}
+	if !isGeneratedCode(src, notice) {
+		return fmt.Errorf("%s exists and isn't generated code, so it isn't overwritten; rename or remove it", filename)
+	}
+	return nil
+}
+
+// isGeneratedCode reports whether src starts with the notice generation writes, or has a
+// "Code generated ... DO NOT EDIT." comment that Go tooling recognizes
+func isGeneratedCode(src []byte, notice string) bool {
+	if notice != "" && bytes.HasPrefix(src, []byte(notice)) {
+		return true
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	return err == nil && ast.IsGenerated(file)
+}
+
+// pruneImports removes the imports src doesn't use, like goimports does, so templates
+// can import packages only some classes need
+func pruneImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Package-level identifiers selected from are the names of imports
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	// Drop the lines of unused imports, or of the whole declaration when none is left
+	var remove [][2]int
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		var unused []ast.Spec
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			name := importName(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name != "_" && name != "." && !used[name] {
+				unused = append(unused, spec)
+			}
+		}
+		if len(unused) == len(gen.Specs) {
+			remove = append(remove, lineRange(fset, src, gen.Pos(), gen.End()))
+			continue
+		}
+		for _, spec := range unused {
+			remove = append(remove, lineRange(fset, src, spec.Pos(), spec.End()))
+		}
+	}
+	if len(remove) == 0 {
+		return src, nil
+	}
+
+	var pruned []byte
+	start := 0
+	for _, r := range remove {
+		pruned = append(pruned, src[start:r[0]]...)
+		start = r[1]
+	}
+	pruned = append(pruned, src[start:]...)
+	return format.Source(pruned)
+}
+
+// lineRange returns the offsets of the whole lines from pos to end in src
+func lineRange(fset *token.FileSet, src []byte, pos, end token.Pos) [2]int {
+	from := bytes.LastIndexByte(src[:fset.Position(pos).Offset], '\n') + 1
+	to := len(src)
+	if i := bytes.IndexByte(src[fset.Position(end).Offset:], '\n'); i >= 0 {
+		to = fset.Position(end).Offset + i + 1
+	}
+	return [2]int{from, to}
+}
+
+// notice renders the header comment and build constraint placed above the package clause
+func (o OutputConfig) notice(sourceHash string) (string, error) {
+	header := o.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	tmpl, err := template.New("header").Parse(header)
+	if err != nil {
+		return "", fmt.Errorf("error parsing output header: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Version    string
+		SourceHash string
+	}{
+		Version:    Version(),
+		SourceHash: sourceHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering output header: %v", err)
+	}
+
+	var notice strings.Builder
+	if o.BuildTags != "" {
+		// A build constraint must be followed by a blank line
+		fmt.Fprintf(&notice, "//go:build %s\n\n", o.BuildTags)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		notice.WriteString(strings.TrimSpace("// "+line) + "\n")
+	}
+
+	return notice.String(), nil
+}
+
+// sourceContext adds the offending line of generated source to a syntax error
+func sourceContext(src []byte, err error) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return err
+	}
+
+	lines := strings.Split(string(src), "\n")
+	line := list[0].Pos.Line
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	return fmt.Errorf("%v\n\t%d: %s", err, line, strings.TrimSpace(lines[line-1]))
+}
+
+// CheckGeneratedCode compiles the generated package with `go build`, so code that
+// would break the user's build is reported at generation time. outputDir must be
+// inside the Go module holding the model structs.
+func CheckGeneratedCode(outputDir string) error {
+	cmd := exec.Command("go", "build", ".")
+	cmd.Dir = outputDir
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("generated code in %s doesn't compile: %v\n%s", outputDir, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // generateClientCode creates the base Weaviate client code
-func generateClientCode(packageName string, outputDir string) error {
+func generateClientCode(packageName, notice string, outputDir string) error {
 
 	templateData := TemplateData[struct{}]{
-		PackageName: packageName,
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
 	}
 
 	return generateFromTemplate("client", templateData, filepath.Join(outputDir, "client.go"))
 }
 
-// generateClassCRUD generates CRUD code for a specific class
-func generateClassCRUD(packageName string, class WeaviateClass, outputDir string) error {
-	// Create template data
-	idField := "ID" // Default ID field name
+// generateSharedCode generates a class-independent file named weave_<template>.go, so it
+// doesn't take a name the package's own files are likely to have. The file of the same
+// name without the prefix, written by earlier versions, is removed when it's generated.
+func generateSharedCode(src string, packageName, notice string, outputDir string) error {
+	templateData := TemplateData[struct{}]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+	}
+
+	if err := generateFromTemplate(src, templateData, filepath.Join(outputDir, "weave_"+src+".go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, src+".go"), notice)
+}
+
+// removeGeneratedFile removes filename when it exists and is generated code
+func removeGeneratedFile(filename, notice string) error {
+	src, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", filename, err)
+	}
+	if !isGeneratedCode(src, notice) {
+		return nil
+	}
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("error removing %s: %v", filename, err)
+	}
+	return nil
+}
+
+// generateCloudCode generates NewCloudClient, sending the inference API key headers of cfg
+func generateCloudCode(packageName, notice string, cfg CloudConfig, outputDir string) error {
+	// Header is an inference API key header and the environment variable holding the key
+	type Header struct {
+		Name string
+		Env  string
+	}
+
+	templateData := TemplateData[[]Header]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Headers)) {
+		templateData.Data = append(templateData.Data, Header{Name: name, Env: cfg.Headers[name]})
+	}
+
+	if err := generateFromTemplate("cloud", templateData, filepath.Join(outputDir, "weave_cloud.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "cloud.go"), notice)
+}
+
+// generateHandlers generates NewHandler, serving the CRUD operations of every class over HTTP
+func generateHandlers(packageName, notice string, schema *WeaviateSchemaDefinition, outputDir string) error {
+	// Route is the routes of a class, under its lowercase name
+	type Route struct {
+		Class    string
+		Path     string
+		ReadOnly bool   // only the GET routes
+		Version  string // Go field of the version property, incremented by Update
+	}
+
+	templateData := TemplateData[[]Route]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+	}
+	for _, class := range schema.Classes {
+		route := Route{Class: class.Class, Path: strings.ToLower(class.Class), ReadOnly: class.ReadOnly}
+		for _, prop := range class.Properties {
+			if prop.Version {
+				route.Version = prop.GoField
+			}
+		}
+		templateData.Data = append(templateData.Data, route)
+	}
+
+	if err := generateFromTemplate("handlers", templateData, filepath.Join(outputDir, "weave_handlers.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "handlers.go"), notice)
+}
+
+// generateBackupCode generates the backup and restore methods of the client
+func generateBackupCode(packageName, notice string, schema *WeaviateSchemaDefinition, outputDir string) error {
+	templateData := TemplateData[[]string]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+		Data:                schemaClassNames(schema),
+	}
+	if err := generateFromTemplate("backup", templateData, filepath.Join(outputDir, "weave_backup.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "backup.go"), notice)
+}
+
+// generateVerifyCode generates VerifySchema with the property data types each class expects
+func generateVerifyCode(packageName, notice string, schema *WeaviateSchemaDefinition, outputDir string) error {
+	// ExpectedProperty is a property the generated code uses, nested ones named by their path
+	type ExpectedProperty struct {
+		Path     string
+		DataType string
+	}
+	// ExpectedClass is a generated class and the properties it expects
+	type ExpectedClass struct {
+		Class      string
+		Properties []ExpectedProperty
+	}
+
+	templateData := TemplateData[[]ExpectedClass]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+	}
+	for _, class := range schema.Classes {
+		expected := ExpectedClass{Class: class.Class}
+		var walk func(prefix string, props []WeaviateProperty)
+		walk = func(prefix string, props []WeaviateProperty) {
+			for _, prop := range props {
+				expected.Properties = append(expected.Properties, ExpectedProperty{
+					Path:     prefix + prop.Name,
+					DataType: strings.Join(prop.DataType, ","),
+				})
+				walk(prefix+prop.Name+".", prop.NestedProperties)
+			}
+		}
+		walk("", class.Properties)
+		templateData.Data = append(templateData.Data, expected)
+	}
+	if err := generateFromTemplate("verify", templateData, filepath.Join(outputDir, "weave_verify.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "verify.go"), notice)
+}
+
+// generateBackfillCode generates weave_backfill.go with the backfill registry and RunBackfills.
+// It's generated without backfills too, so removing the last one doesn't leave a stale registry.
+func generateBackfillCode(packageName, notice string, backfills []Backfill, outputDir string) error {
+	templateData := TemplateData[[]Backfill]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+		Data:                backfills,
+	}
+	if err := generateFromTemplate("backfill", templateData, filepath.Join(outputDir, "weave_backfill.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "backfill.go"), notice)
+}
+
+// defaultTestRelease is the Weaviate release of the generated test harness and of Weaviate
+// Embedded when no version is targeted
+const defaultTestRelease = "1.25.4"
+
+// testRelease returns the Weaviate release the generated code runs for tests: the first of
+// the targeted version, or defaultTestRelease
+func testRelease(cfg *Config) (string, error) {
+	if cfg.WeaviateVersion == "" {
+		return defaultTestRelease, nil
+	}
+	version, err := ParseWeaviateVersion(cfg.WeaviateVersion)
+	if err != nil {
+		return "", err
+	}
+	return version.String() + ".0", nil
+}
+
+// generateEmbeddedCode generates StartEmbedded and NewEmbeddedClient running Weaviate Embedded
+func generateEmbeddedCode(packageName, notice string, cfg *Config, outputDir string) error {
+	release, err := testRelease(cfg)
+	if err != nil {
+		return err
+	}
+	templateData := TemplateData[string]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+		Data:                release,
+	}
+	if err := generateFromTemplate("embedded", templateData, filepath.Join(outputDir, "weave_embedded.go")); err != nil {
+		return err
+	}
+	return removeGeneratedFile(filepath.Join(outputDir, "embedded.go"), notice)
+}
+
+// generateTestCode generates the testcontainers-go harness and fixture builders
+func generateTestCode(packageName, notice string, schema *WeaviateSchemaDefinition, cfg *Config, outputDir string) error {
+	schemaJSON, err := schema.ToJSON(true)
+	if err != nil {
+		return fmt.Errorf("error marshaling schema for test code: %v", err)
+	}
+
+	// A raw string keeps the schema readable unless it contains a backquote
+	schemaLiteral := "`" + string(schemaJSON) + "`"
+	if strings.Contains(string(schemaJSON), "`") {
+		schemaLiteral = strconv.Quote(string(schemaJSON))
+	}
+
+	release, err := testRelease(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Class is a class fixtures are generated for
+	type Class struct {
+		Name     string
+		ReadOnly bool // stored without the CRUD, which can't write
+	}
+
+	type Data struct {
+		Image    string
+		Schema   string // Go string literal
+		Classes  []Class
+		Embedded bool // startWeaviate can run Weaviate Embedded instead
+	}
+
+	templateData := TemplateData[Data]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		Data: Data{
+			Image:    "cr.weaviate.io/semitechnologies/weaviate:" + release,
+			Schema:   schemaLiteral,
+			Embedded: cfg.Output.Embedded,
+		},
+	}
+	for _, class := range schema.Classes {
+		templateData.Data.Classes = append(templateData.Data.Classes, Class{Name: class.Class, ReadOnly: class.ReadOnly})
+	}
+
+	return generateFromTemplate("testcode", templateData, filepath.Join(outputDir, "weave_helpers_test.go"))
+}
+
+// generateBenchCode generates weave_bench_test.go with the helpers shared by the benchmarks,
+// and a benchmark per class
+func generateBenchCode(packageName, notice string, schema *WeaviateSchemaDefinition, outputDir string) error {
+	templateData := TemplateData[struct{}]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
+	}
+	if err := generateFromTemplate("bench", templateData, filepath.Join(outputDir, "weave_bench_test.go")); err != nil {
+		return err
+	}
+
+	// NamedVector is the named vector NearVector searches
+	type NamedVector struct {
+		GoName string
+	}
+
+	type Data struct {
+		ClassName     string
+		ReadOnly      bool         // only Get and NearVector are measured
+		RandomVectors bool         // the class has no vectorizer, so objects get random vectors
+		Vector        *NamedVector // the first named vector, when the class has a vectorConfig
+	}
+
+	for _, class := range schema.Classes {
+		data := Data{
+			ClassName:     class.Class,
+			ReadOnly:      class.ReadOnly,
+			RandomVectors: class.Vectorizer == "none" && len(class.VectorConfig) == 0,
+		}
+		if names := slices.Sorted(maps.Keys(class.VectorConfig)); len(names) > 0 {
+			data.Vector = &NamedVector{GoName: exportedName(names[0])}
+		}
+
+		classData := TemplateData[Data]{
+			AutogeneratedNotice: notice,
+			PackageName:         packageName,
+			WeaviatePackage:     WeaviatePackage,
+			Data:                data,
+		}
+		path := filepath.Join(outputDir, strings.ToLower(class.Class)+"_bench_test.go")
+		if err := generateFromTemplate("class_bench", classData, path); err != nil {
+			return fmt.Errorf("error generating benchmark for class %s: %v", class.Class, err)
+		}
+	}
+	return nil
+}
+
+// helperTypeFields are the subfields GraphQL selects for the helper data types
+var helperTypeFields = map[string][]string{
+	"geoCoordinates": {"latitude", "longitude"},
+	"phoneNumber":    {"input", "defaultCountry", "internationalFormatted", "countryCode", "national", "nationalFormatted", "valid"},
+}
+
+// imageModulePrefixes are the prefixes of the vectorizer modules serving nearImage searches
+var imageModulePrefixes = []string{"multi2vec-", "img2vec-"}
+
+// vectorizesImages reports whether the vectorizer of a class, or of one of its named vectors,
+// serves nearImage searches
+func vectorizesImages(class WeaviateClass) bool {
+	modules := []string{class.Vectorizer}
+	for _, config := range class.VectorConfig {
+		vector, _ := config.(map[string]interface{})
+		vectorizer, _ := vector["vectorizer"].(map[string]interface{})
+		modules = slices.AppendSeq(modules, maps.Keys(vectorizer))
+	}
+	for _, module := range modules {
+		for _, prefix := range imageModulePrefixes {
+			if strings.HasPrefix(module, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rangeValueTypes are the Go type and WhereBuilder method of the values compared by range filters
+var rangeValueTypes = map[string][2]string{
+	"int":    {"int64", "WithValueInt"},
+	"number": {"float64", "WithValueNumber"},
+	"date":   {"time.Time", "WithValueDate"},
+}
 
-	// Find ID field
+// graphqlField returns a graphql.Field literal selecting prop, with the subfields of
+// nested objects and the IDs of referenced objects, which GraphQL can't select as a whole
+func graphqlField(prop WeaviateProperty) string {
+	if len(prop.DataType) == 1 && isReferenceType(prop.DataType[0]) {
+		return fmt.Sprintf("{Name: %q, Fields: []graphql.Field{{Name: %q, Fields: []graphql.Field{{Name: \"_additional\", Fields: []graphql.Field{{Name: \"id\"}}}}}}}",
+			prop.Name, "... on "+prop.DataType[0])
+	}
+	if subfields, ok := helperTypeFields[strings.Join(prop.DataType, ",")]; ok {
+		fields := make([]string, len(subfields))
+		for i, subfield := range subfields {
+			fields[i] = fmt.Sprintf("{Name: %q}", subfield)
+		}
+		return fmt.Sprintf("{Name: %q, Fields: []graphql.Field{%s}}", prop.Name, strings.Join(fields, ", "))
+	}
+	if len(prop.NestedProperties) == 0 {
+		return fmt.Sprintf("{Name: %q}", prop.Name)
+	}
+	fields := make([]string, len(prop.NestedProperties))
+	for i, nested := range prop.NestedProperties {
+		fields[i] = graphqlField(nested)
+	}
+	return fmt.Sprintf("{Name: %q, Fields: []graphql.Field{%s}}", prop.Name, strings.Join(fields, ", "))
+}
+
+// idProperty returns the property holding the Weaviate object ID of a class, if any
+func idProperty(class WeaviateClass) (WeaviateProperty, bool) {
 	for _, prop := range class.Properties {
 		if strings.ToLower(prop.Name) == "id" || strings.HasSuffix(strings.ToLower(prop.Name), "_id") {
-			// Convert to Go field name format (camelCase to PascalCase)
-			idField = toPascalCase(prop.Name)
-			break
+			return prop, true
 		}
 	}
+	return WeaviateProperty{}, false
+}
 
-	type Data struct {
-		ClassName  string
-		IDField    string
-		Properties []struct {
-			Name string
+// propertyKeysLiteral returns the propertyKeys literal mapping the JSON keys of the fields of
+// props to their properties, where they differ or those of their nested properties do, or ""
+// when none do
+func propertyKeysLiteral(props []WeaviateProperty) string {
+	var entries []string
+	for _, prop := range props {
+		nested := propertyKeysLiteral(prop.NestedProperties)
+		if prop.JSONKey == "" && nested == "" {
+			continue
+		}
+		entry := fmt.Sprintf("%q: {name: %q", prop.fieldKey(), prop.Name)
+		if nested != "" {
+			entry += ", nested: " + nested
 		}
+		entries = append(entries, entry+"}")
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	return "propertyKeys{" + strings.Join(entries, ", ") + "}"
+}
+
+// exportedName converts a name like title_vector into an exported Go identifier, TitleVector
+func exportedName(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	return strings.Join(words, "")
+}
+
+// generateClassCRUD generates CRUD code for a specific class
+func generateClassCRUD(packageName, notice string, class WeaviateClass, schema *WeaviateSchemaDefinition, outputDir string) error {
+	// Find the field holding the Weaviate object ID, if any
+	var idField, idExpr string
+	if prop, ok := idProperty(class); ok {
+		idField = prop.GoField
+		idExpr = "obj." + idField
+		if prop.GoType != "string" {
+			// uuid.UUID and similar types
+			idExpr += ".String()"
+		}
+	}
+
+	// Reference is a reference property decoded into the target's struct
+	// and encoded as beacons to the referenced IDs
+	type Reference struct {
+		Name       string
+		GoField    string
+		Target     string
+		IDProperty string // JSON key of the target's field receiving the referenced ID
+		IDExpr     string // expression reading the ID of ref, empty when the target has none
+		Single     bool   // the field holds one object rather than a slice
+		Pointer    bool   // the field, or its elements, are pointers
+		Ref        bool   // the field, or its elements, are Ref[T] from weave types
+	}
+
+	// PhoneNumber is a phone number property, of which Weaviate only accepts the input
+	type PhoneNumber struct {
+		Name    string
+		GoField string
+		Pointer bool
+	}
+
+	type EnumField struct {
+		GoField string
+		Enum    string
+		Slice   bool
+		Pointer bool
+	}
+
+	// GeoProperty is a geoCoordinates property, filtered with FilterWithinGeoRange
+	type GeoProperty struct {
+		Name    string
+		GoField string
+	}
+
+	// RangeProperty is a property with a range index, filtered with the <Class><Field>Between,
+	// Above and Below functions
+	type RangeProperty struct {
+		Name      string
+		GoField   string
+		ValueType string // Go type of the filter values
+		WithValue string // WhereBuilder method setting the value
+	}
+
+	// BlobProperty is a property of blob type, with Upload and Download methods streaming it
+	type BlobProperty struct {
+		Name    string
+		GoField string
+	}
+
+	// PassthroughProperty is a map field tagged type=object, written with whatever keys it has
+	type PassthroughProperty struct {
+		Name    string
+		GoField string
+		Pointer bool
+	}
+
+	// RenamedProperty is a property renamed with the renamedFrom tag option, decoded from its
+	// former name while objects still hold it
+	type RenamedProperty struct {
+		Name string
+		From string
+	}
+
+	// NamedVector is a named vector of the class's vector config
+	type NamedVector struct {
+		Name   string
+		GoName string
+	}
+
+	type Data struct {
+		ClassName      string
+		IDField        string
+		IDExpr         string
+		Fields         []string // graphql.Field expressions selecting the properties
+		JSONProperties []string // properties stored as JSON text
+		JSONKeys       []string // JSON keys of the fields of those properties
+		Keys           string   // propertyKeys literal renaming JSON keys to properties, if any differ
+		Deprecated     []string // properties the generated code no longer writes
+		Renamed        []RenamedProperty
+		References     []Reference
+		PhoneNumbers   []PhoneNumber
+		GeoProperties  []GeoProperty
+		Ranges         []RangeProperty
+		DateRanges     bool // whether range filters compare times
+		Vectors        []NamedVector
+		Blobs          []BlobProperty
+		Passthrough    []PassthroughProperty
+		NearImage      bool // a vectorizer of the class vectorizes images
+		Properties     []WeaviateProperty
+		EnumFields     []EnumField
+
+		// Consistency levels the class declares
+		ReadConsistency  string
+		WriteConsistency string
+
+		// ReadOnly leaves out Create, CreateMany, Importer, Update, Delete and DeleteWhere
+		ReadOnly bool
+
+		// Version is the Go field of the version property Update compares and increments
+		Version string
 	}
 
 	templateData := TemplateData[Data]{
-		PackageName:     packageName,
-		WeaviatePackage: WeaviatePackage,
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		WeaviatePackage:     WeaviatePackage,
 		Data: Data{
 			ClassName:  class.Class,
 			IDField:    idField,
-			Properties: []struct{ Name string }{},
+			IDExpr:     idExpr,
+			Properties: class.Properties,
+			Keys:       propertyKeysLiteral(append(slices.Clip(class.Properties), class.Passthrough...)),
+
+			ReadConsistency:  class.ReadConsistency,
+			WriteConsistency: class.WriteConsistency,
+			ReadOnly:         class.ReadOnly,
+			NearImage:        vectorizesImages(class),
 		},
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(class.VectorConfig)) {
+		templateData.Data.Vectors = append(templateData.Data.Vectors, NamedVector{Name: name, GoName: exportedName(name)})
+	}
+
 	// Add properties
 	for _, prop := range class.Properties {
-		templateData.Data.Properties = append(templateData.Data.Properties, struct{ Name string }{
-			Name: prop.Name,
-		})
-	}
+		field := graphqlField(prop)
+		if prop.JSON {
+			templateData.Data.JSONProperties = append(templateData.Data.JSONProperties, prop.Name)
+			templateData.Data.JSONKeys = append(templateData.Data.JSONKeys, prop.fieldKey())
+		}
+		if prop.Deprecated {
+			templateData.Data.Deprecated = append(templateData.Data.Deprecated, prop.Name)
+		}
+		if prop.RenamedFrom != "" {
+			templateData.Data.Renamed = append(templateData.Data.Renamed, RenamedProperty{Name: prop.Name, From: prop.RenamedFrom})
+		}
 
-	return generateFromTemplate("class_crud", templateData, filepath.Join(outputDir, strings.ToLower(class.Class)+"_crud.go"))
-}
+		// References to classes generated along with this one are decoded into their structs
+		if len(prop.DataType) == 1 && isReferenceType(prop.DataType[0]) {
+			if target, ok := schema.class(prop.DataType[0]); ok {
+				elemType, slice := strings.CutPrefix(prop.GoType, "[]")
+				elemType, pointer := strings.CutPrefix(elemType, "*")
+				ref := Reference{
+					Name:    prop.Name,
+					GoField: prop.GoField,
+					Target:  target.Class,
+					Single:  !slice,
+					Pointer: pointer,
+					Ref:     strings.HasPrefix(elemType, "Ref["),
+				}
+				if id, ok := idProperty(target); ok {
+					ref.IDProperty = id.fieldKey()
+					ref.IDExpr = "ref." + id.GoField
+					if id.GoType != "string" {
+						ref.IDExpr += ".String()"
+					}
+				}
+				if ref.Ref {
+					ref.IDExpr = "ref.ID"
+				}
+				templateData.Data.References = append(templateData.Data.References, ref)
 
-// toPascalCase converts a string from camelCase or snake_case to PascalCase
-func toPascalCase(s string) string {
-	// Handle snake_case
-	if strings.Contains(s, "_") {
-		parts := strings.Split(s, "_")
-		for i, part := range parts {
-			if len(part) > 0 {
-				parts[i] = strings.ToUpper(part[:1]) + part[1:]
+				// Resolved as deep as WithReferences asks
+				field = fmt.Sprintf("referenceField(%q, %q, depth(%q), fields%s)", prop.Name, target.Class, prop.Name, target.Class)
 			}
 		}
-		return strings.Join(parts, "")
+
+		templateData.Data.Fields = append(templateData.Data.Fields, field)
+
+		if len(prop.DataType) == 1 && prop.DataType[0] == "phoneNumber" {
+			templateData.Data.PhoneNumbers = append(templateData.Data.PhoneNumbers, PhoneNumber{
+				Name:    prop.Name,
+				GoField: prop.GoField,
+				Pointer: strings.HasPrefix(prop.GoType, "*"),
+			})
+		}
+
+		if len(prop.DataType) == 1 && prop.DataType[0] == "geoCoordinates" {
+			templateData.Data.GeoProperties = append(templateData.Data.GeoProperties, GeoProperty{Name: prop.Name, GoField: prop.GoField})
+		}
+
+		if prop.IndexRangeFilters {
+			templateData.Data.Ranges = append(templateData.Data.Ranges, RangeProperty{
+				Name:      prop.Name,
+				GoField:   prop.GoField,
+				ValueType: rangeValueTypes[prop.DataType[0]][0],
+				WithValue: rangeValueTypes[prop.DataType[0]][1],
+			})
+			templateData.Data.DateRanges = templateData.Data.DateRanges || prop.DataType[0] == "date"
+		}
+
+		if prop.Version {
+			templateData.Data.Version = prop.GoField
+		}
+
+		if len(prop.DataType) == 1 && prop.DataType[0] == "blob" {
+			templateData.Data.Blobs = append(templateData.Data.Blobs, BlobProperty{Name: prop.Name, GoField: prop.GoField})
+		}
+
+		if prop.Enum != "" {
+			templateData.Data.EnumFields = append(templateData.Data.EnumFields, EnumField{
+				GoField: prop.GoField,
+				Enum:    prop.Enum,
+				Slice:   strings.HasPrefix(prop.GoType, "[]"),
+				Pointer: strings.HasPrefix(prop.GoType, "*"),
+			})
+		}
 	}
 
-	// Handle camelCase
-	if len(s) > 0 {
-		return strings.ToUpper(s[:1]) + s[1:]
+	for _, prop := range class.Passthrough {
+		templateData.Data.Passthrough = append(templateData.Data.Passthrough, PassthroughProperty{
+			Name:    prop.Name,
+			GoField: prop.GoField,
+			Pointer: strings.HasPrefix(prop.GoType, "*"),
+		})
 	}
 
-	return s
+	return generateFromTemplate("class_crud", templateData, filepath.Join(outputDir, strings.ToLower(class.Class)+"_crud.go"))
 }
@@ -0,0 +1,440 @@
+package weave
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// roundTripSource is a package with fields named by the naming strategy, at the top level
+// and in nested objects, which roundTripMain encodes and decodes with the generated code
+const roundTripSource = `package main
+
+type Address struct {
+	StreetName string
+	ZipCode    string ` + "`json:\"zip\"`" + `
+}
+
+// +weave
+type Article struct {
+	UserID    string
+	PageCount int
+	Title     string            ` + "`json:\"title\"`" + `
+	Meta      map[string]string ` + "`weave:\"type=text\"`" + `
+	Address   Address
+	Addresses []Address
+}
+`
+
+// roundTripMain prints the properties Article encodes to, as Weaviate stores them, whether
+// decoding them gives back the struct, and the property WithStrictProperties rejects, if any
+const roundTripMain = `package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+)
+
+func main() {
+	in := Article{
+		UserID:    "u1",
+		PageCount: 3,
+		Title:     "Title",
+		Meta:      map[string]string{"Key": "value"},
+		Address:   Address{StreetName: "Main Street", ZipCode: "1234"},
+		Addresses: []Address{{StreetName: "Side Street"}},
+	}
+	properties, err := encodeJSONProperties(in, keysArticle, "meta")
+	if err != nil {
+		panic(err)
+	}
+
+	// Weaviate returns the properties as plain JSON values
+	data, err := json.Marshal(properties)
+	if err != nil {
+		panic(err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		panic(err)
+	}
+	out, err := decodeProperties[Article](keysArticle.fields(stored), "Article", "Meta")
+	if err != nil {
+		panic(err)
+	}
+
+	unknown, _ := unknownProperty(properties, propertiesArticle)
+	json.NewEncoder(os.Stdout).Encode(map[string]interface{}{"properties": stored, "roundTrip": reflect.DeepEqual(in, out), "unknown": unknown})
+}
+`
+
+// roundTripResult is the output of roundTripMain
+type roundTripResult struct {
+	Properties map[string]interface{} `json:"properties"`
+	RoundTrip  bool                   `json:"roundTrip"`
+	Unknown    string                 `json:"unknown"`
+}
+
+// runRoundTrip generates the code converting Article of roundTripSource with naming, and runs
+// roundTripMain with it
+func runRoundTrip(t *testing.T, naming NamingStrategy) (WeaviateClass, roundTripResult) {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is needed to run the generated code")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":    "module roundtrip\n\ngo 1.23\n",
+		"models.go": roundTripSource,
+		"main.go":   roundTripMain,
+	})
+
+	schema, diags, err := GenerateWeaviateSchemaWithConfig(dir, &Config{Naming: naming})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	class, ok := schema.class("Article")
+	if !ok {
+		t.Fatal("no Article class")
+	}
+
+	// The shared code as generated, and the variables of the class's code it uses
+	const notice = "// Code generated by weave. DO NOT EDIT."
+	if err := generateSharedCode("properties", "main", notice, dir); err != nil {
+		t.Fatal(err)
+	}
+	classCode := notice + "\n\npackage main\n\nvar keysArticle propertyKeys"
+	if keys := propertyKeysLiteral(class.Properties); keys != "" {
+		classCode += " = " + keys
+	}
+	classCode += "\n\nvar propertiesArticle = []string{"
+	for _, prop := range class.Properties {
+		classCode += strconv.Quote(prop.Name) + ", "
+	}
+	classCode += "}\n"
+	if err := os.WriteFile(filepath.Join(dir, "class.go"), []byte(classCode), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var result roundTripResult
+	goRun(t, goTool, dir, &result)
+	return class, result
+}
+
+// goRun runs the main package in dir, decoding the JSON it prints into result
+func goRun(t *testing.T, goTool, dir string, result interface{}) {
+	t.Helper()
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	output, err := cmd.Output()
+	if err != nil {
+		var stderr []byte
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = exitErr.Stderr
+		}
+		t.Fatalf("running the generated code: %v\n%s", err, stderr)
+	}
+	if err := json.Unmarshal(output, result); err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+}
+
+// writeFiles writes files, by name, into dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGeneratedPropertyKeysRoundTrip(t *testing.T) {
+	class, result := runRoundTrip(t, NamingSnakeCase)
+
+	// Every property is written under the name the schema declares, nested ones too
+	for _, prop := range class.Properties {
+		value, ok := result.Properties[prop.Name]
+		if !ok {
+			t.Errorf("property %s isn't written, the properties are %v", prop.Name, result.Properties)
+			continue
+		}
+		var object map[string]interface{}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			object = v
+		case []interface{}:
+			object, _ = v[0].(map[string]interface{})
+		}
+		for _, nested := range prop.NestedProperties {
+			if _, ok := object[nested.Name]; !ok {
+				t.Errorf("nested property %s.%s isn't written, the object is %v", prop.Name, nested.Name, object)
+			}
+		}
+	}
+	if len(result.Properties) != len(class.Properties) {
+		names := make([]string, len(class.Properties))
+		for i, prop := range class.Properties {
+			names[i] = prop.Name
+		}
+		t.Errorf("written properties %v, the schema declares %v", result.Properties, names)
+	}
+	if meta, ok := result.Properties["meta"].(string); !ok || !json.Valid([]byte(meta)) {
+		t.Errorf("meta is written as %v, want JSON text", result.Properties["meta"])
+	}
+	if !result.RoundTrip {
+		t.Errorf("decoding the written properties doesn't give back the struct: %v", result.Properties)
+	}
+
+	// The snake_case names the test relies on
+	for _, name := range []string{"user_id", "page_count", "title", "meta", "address", "addresses"} {
+		if !slices.ContainsFunc(class.Properties, func(p WeaviateProperty) bool { return p.Name == name }) {
+			t.Errorf("the schema has no property %s", name)
+		}
+	}
+}
+
+func TestGeneratedStrictPropertiesAcceptUntaggedFields(t *testing.T) {
+	// Untagged fields are encoded under their Go names, which camelCase renames
+	class, result := runRoundTrip(t, NamingCamelCase)
+	if !slices.ContainsFunc(class.Properties, func(p WeaviateProperty) bool { return p.Name == "userID" }) {
+		t.Fatalf("the schema has no property userID: %v", class.Properties)
+	}
+	if result.Unknown != "" {
+		t.Errorf("WithStrictProperties rejects property %s of %v", result.Unknown, result.Properties)
+	}
+	if !result.RoundTrip {
+		t.Errorf("decoding the written properties doesn't give back the struct: %v", result.Properties)
+	}
+}
+
+// versionSource is a package with a class holding a version, which versionMain updates
+const versionSource = `package main
+
+// +weave
+type Article struct {
+	Title   string
+	Version int64 ` + "`json:\"version\" weave:\"version\"`" + `
+}
+`
+
+// versionStubs stand in for the client the generated Update runs on, storing one object
+const versionStubs = `package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type Option func(*operation)
+
+type operation struct{}
+
+func (op operation) checkProperties(className string, properties map[string]interface{}, declared []string) error {
+	if name, ok := unknownProperty(properties, declared); ok {
+		return fmt.Errorf("unknown property %s", name)
+	}
+	return nil
+}
+
+func WithoutCache() Option { return func(*operation) {} }
+
+type Op string
+
+const OpUpdate Op = "update"
+
+type Call struct {
+	Op     Op
+	Class  string
+	ID     string
+	Object any
+}
+
+func conflictError(op string, err error) error { return fmt.Errorf("conflict %s: %w", op, err) }
+
+func wrapError(op string, err error) error { return fmt.Errorf("error %s: %w", op, err) }
+
+type Client struct {
+	stored []byte
+}
+
+func (c *Client) operation(opts []Option) operation { return operation{} }
+
+func (c *Client) run(ctx context.Context, op operation, call *Call, fn func(context.Context, *Call) error) error {
+	return fn(ctx, call)
+}
+
+type updater struct {
+	client     *Client
+	properties map[string]interface{}
+}
+
+func (c *Client) updater(className, id string, op operation) *updater { return &updater{client: c} }
+
+func (u *updater) WithProperties(properties map[string]interface{}) *updater {
+	u.properties = properties
+	return u
+}
+
+func (u *updater) Do(ctx context.Context) error {
+	stored, err := json.Marshal(u.properties)
+	u.client.stored = stored
+	return err
+}
+
+type ArticleCRUD struct {
+	client *Client
+}
+
+func (c *ArticleCRUD) Get(ctx context.Context, id string, opts ...Option) (*Article, error) {
+	var properties map[string]interface{}
+	if err := json.Unmarshal(c.client.stored, &properties); err != nil {
+		return nil, err
+	}
+	obj, err := decodeProperties[Article](keysArticle.fields(properties), "Article")
+	return &obj, err
+}
+`
+
+// versionMain updates an object twice in a row, then with a stale copy
+const versionMain = `package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+func main() {
+	ctx := context.Background()
+	crud := &ArticleCRUD{client: &Client{stored: []byte(` + "`{\"title\":\"draft\",\"version\":0}`" + `)}}
+
+	var result struct {
+		Versions []int64
+		Errors   []string
+		Stored   Article
+	}
+	obj := Article{Title: "first"}
+	stale := obj
+	for _, title := range []string{"first", "second"} {
+		obj.Title = title
+		err := crud.Update(ctx, "id", &obj)
+		result.Versions = append(result.Versions, obj.Version)
+		result.Errors = append(result.Errors, errorString(err))
+	}
+	result.Errors = append(result.Errors, errorString(crud.Update(ctx, "id", &stale)))
+	stored, _ := crud.Get(ctx, "id")
+	result.Stored = *stored
+	json.NewEncoder(os.Stdout).Encode(result)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+`
+
+// versionDecls are the declarations of the generated class code versionMain runs
+var versionDecls = []string{"Update", "validate", "options", "EncodeArticle", "keysArticle", "propertiesArticle"}
+
+func TestGeneratedUpdateKeepsVersion(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is needed to run the generated code")
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":    "module version\n\ngo 1.23\n",
+		"models.go": versionSource,
+		"stubs.go":  versionStubs,
+		"main.go":   versionMain,
+	})
+	schema, diags, err := GenerateWeaviateSchemaWithConfig(dir, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	class, _ := schema.class("Article")
+
+	// The class code needs the Weaviate client, so only the declarations Update runs are
+	// taken from it, next to the shared code
+	const notice = "// Code generated by weave. DO NOT EDIT."
+	genDir := t.TempDir()
+	if err := generateClassCRUD("main", notice, class, schema, genDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateSharedCode("properties", "main", notice, dir); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(genDir, "article_crud.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code bytes.Buffer
+	code.WriteString("package main\n\nimport (\n\"context\"\n\"fmt\"\n)\n")
+	for _, decl := range file.Decls {
+		var name string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name = d.Name.Name
+		case *ast.GenDecl:
+			if spec, ok := d.Specs[0].(*ast.ValueSpec); ok {
+				name = spec.Names[0].Name
+			}
+		}
+		if !slices.Contains(versionDecls, name) {
+			continue
+		}
+		code.WriteString("\n")
+		if err := printer.Fprint(&code, fset, decl); err != nil {
+			t.Fatal(err)
+		}
+		code.WriteString("\n")
+	}
+	src, err := pruneImports(code.Bytes())
+	if err != nil {
+		t.Fatalf("%v\n%s", err, code.Bytes())
+	}
+	writeFiles(t, dir, map[string]string{"class.go": string(src)})
+
+	var result struct {
+		Versions []int64
+		Errors   []string
+		Stored   map[string]interface{}
+	}
+	goRun(t, goTool, dir, &result)
+
+	if !slices.Equal(result.Versions, []int64{1, 2}) || result.Errors[0] != "" || result.Errors[1] != "" {
+		t.Errorf("two updates in a row leave the object at versions %v, with errors %q", result.Versions, result.Errors[:2])
+	}
+	if !strings.Contains(result.Errors[2], "conflict") {
+		t.Errorf("updating a stale copy returns %q, want a conflict", result.Errors[2])
+	}
+	if result.Stored["Title"] != "second" || result.Stored["version"] != 2.0 {
+		t.Errorf("the stored object is %v, want the second update at version 2", result.Stored)
+	}
+}
@@ -0,0 +1,133 @@
+package weave
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strconv"
+)
+
+// Enum describes a Go string type with declared constant values
+type Enum struct {
+	Name   string      `json:"name"`
+	Values []EnumValue `json:"values"`
+
+	Dir string `json:"-"` // Directory of the package declaring the enum
+}
+
+// EnumValue is a single constant of an Enum
+type EnumValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// collectEnums finds string-typed enums (a defined string type with const values) declared in the files
+func collectEnums(files []*ast.File) map[string]*Enum {
+	// First pass: defined types with string as the underlying type
+	enums := make(map[string]*Enum)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok || typeSpec.Assign.IsValid() || typeSpec.TypeParams != nil {
+					continue
+				}
+				if ident, ok := typeSpec.Type.(*ast.Ident); ok && ident.Name == "string" {
+					enums[typeSpec.Name.Name] = &Enum{Name: typeSpec.Name.Name}
+				}
+			}
+		}
+	}
+
+	// Second pass: constants of those types
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, name := range valueSpec.Names {
+					if i >= len(valueSpec.Values) {
+						break
+					}
+					typeName, value, ok := enumConstant(valueSpec.Type, valueSpec.Values[i])
+					if !ok {
+						continue
+					}
+					if enum, ok := enums[typeName]; ok {
+						enum.Values = append(enum.Values, EnumValue{Name: name.Name, Value: value})
+					}
+				}
+			}
+		}
+	}
+
+	// A string type without constants is just text
+	for name, enum := range enums {
+		if len(enum.Values) == 0 {
+			delete(enums, name)
+		}
+	}
+
+	return enums
+}
+
+// enumConstant extracts the type and value of `X Type = "v"` and `X = Type("v")` constants
+func enumConstant(typ ast.Expr, value ast.Expr) (string, string, bool) {
+	if call, ok := value.(*ast.CallExpr); ok && typ == nil && len(call.Args) == 1 {
+		typ = call.Fun
+		value = call.Args[0]
+	}
+
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+
+	lit, ok := value.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", "", false
+	}
+
+	str, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", "", false
+	}
+
+	return ident.Name, str, true
+}
+
+// enumFieldType resolves a field of type E, *E or []E to the enum E, reporting whether it's a slice
+func enumFieldType(expr ast.Expr, enums map[string]*Enum) (enum *Enum, isSlice bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return enums[t.Name], false
+	case *ast.StarExpr:
+		return enumFieldType(t.X, enums)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && t.Len == nil {
+			return enums[ident.Name], true
+		}
+	}
+	return nil, false
+}
+
+// generateEnumCode generates validation helpers for the enums used by the schema
+func generateEnumCode(packageName, notice string, enums []Enum, outputDir string) error {
+	templateData := TemplateData[[]Enum]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		Data:                enums,
+	}
+
+	return generateFromTemplate("enums", templateData, filepath.Join(outputDir, "weave_enums.go"))
+}
@@ -0,0 +1,130 @@
+package weave
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// fakeField is a struct field assigned by a generated fake constructor
+type fakeField struct {
+	GoField string
+	Expr    string // Go expression producing a random value from r
+}
+
+// fakeClass holds the fields of one generated Fake<Class> constructor
+type fakeClass struct {
+	ClassName string
+	Fields    []fakeField
+}
+
+// generateFakes generates Fake<Class> constructors for every class
+func generateFakes(packageName, notice string, schema *WeaviateSchemaDefinition, outputDir string) error {
+	classes := make(map[string]bool, len(schema.Classes))
+	for _, class := range schema.Classes {
+		classes[class.Class] = true
+	}
+
+	var fakes []fakeClass
+	for _, class := range schema.Classes {
+		fake := fakeClass{ClassName: class.Class}
+		for _, prop := range class.Properties {
+			if expr := fakeExpr(prop, prop.GoType, classes, false); expr != "" {
+				fake.Fields = append(fake.Fields, fakeField{GoField: prop.GoField, Expr: expr})
+			}
+		}
+		fakes = append(fakes, fake)
+	}
+
+	templateData := TemplateData[[]fakeClass]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		Data:                fakes,
+	}
+
+	return generateFromTemplate("fakes", templateData, filepath.Join(outputDir, "weave_fakes.go"))
+}
+
+// fakeExpr returns an expression producing a random value of goType for the property,
+// or "" when the type is left at its zero value. Elements of slices get short text.
+func fakeExpr(prop WeaviateProperty, goType string, classes map[string]bool, inSlice bool) string {
+	if elem, ok := strings.CutPrefix(goType, "*"); ok {
+		if expr := fakeExpr(prop, elem, classes, inSlice); expr != "" {
+			return fmt.Sprintf("fakePtr(%s)", expr)
+		}
+		return ""
+	}
+	if elem, ok := strings.CutPrefix(goType, "[]"); ok {
+		if expr := fakeExpr(prop, elem, classes, true); expr != "" {
+			return fmt.Sprintf("fakeSlice(r, func() %s { return %s })", elem, expr)
+		}
+		return ""
+	}
+
+	// Ref[T] and Optional[T] from weave types wrap the fake of T
+	if elem, ok := cutTypeArgument(goType, "Ref"); ok && classes[elem] {
+		return fmt.Sprintf("Ref[%s]{Object: fakePtr(fake%s(r, depth+1))}", elem, elem)
+	}
+	if elem, ok := cutTypeArgument(goType, "Optional"); ok {
+		if expr := fakeExpr(prop, elem, classes, inSlice); expr != "" {
+			return fmt.Sprintf("Some(%s)", expr)
+		}
+		return ""
+	}
+
+	// References are wired to fakes of the target class, up to a fixed depth
+	if classes[goType] {
+		return fmt.Sprintf("fake%s(r, depth+1)", goType)
+	}
+	if prop.Enum == goType {
+		return fmt.Sprintf("fakeChoice(r, %sValues())", goType)
+	}
+
+	switch goType {
+	case "string":
+		return fakeStringExpr(prop, inSlice)
+	case "int":
+		return "r.Intn(1000)"
+	case "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return fmt.Sprintf("%s(r.Intn(1000))", goType)
+	case "float32", "float64":
+		return fmt.Sprintf("%s(r.Float64() * 1000)", goType)
+	case "bool":
+		return "r.Intn(2) == 1"
+	case "time.Time":
+		return "fakeTime(r)"
+	case "time.Duration":
+		return "time.Duration(r.Intn(3600)) * time.Second"
+	}
+	return ""
+}
+
+// cutTypeArgument returns T when goType is the instantiation generic[T]
+func cutTypeArgument(goType, generic string) (string, bool) {
+	elem, ok := strings.CutPrefix(goType, generic+"[")
+	if !ok || !strings.HasSuffix(elem, "]") {
+		return "", false
+	}
+	return strings.TrimSuffix(elem, "]"), true
+}
+
+// fakeStringExpr picks a string generator from the data type and property name
+func fakeStringExpr(prop WeaviateProperty, short bool) string {
+	name := strings.ToLower(prop.Name)
+	switch {
+	case len(prop.DataType) == 1 && strings.TrimSuffix(prop.DataType[0], "[]") == "uuid",
+		name == "id", strings.HasSuffix(name, "_id"), strings.HasSuffix(prop.GoField, "ID"):
+		return "fakeUUID(r)"
+	case strings.Contains(name, "email"):
+		return "fakeEmail(r)"
+	case strings.Contains(name, "url"), strings.Contains(name, "link"):
+		return "fakeURL(r)"
+	case strings.Contains(name, "name"):
+		return "fakeName(r)"
+	case short:
+		return "fakeWords(r, 1, 2)"
+	case strings.Contains(name, "title"):
+		return "fakeWords(r, 3, 6)"
+	}
+	return "fakeWords(r, 8, 20)"
+}
@@ -1,16 +1,27 @@
 package weave
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"go/types"
+	"io"
+	"maps"
+	"os"
 	"path/filepath"
-	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Constants for tag and marker identification
@@ -18,11 +29,19 @@ const (
 	weaviateTag = "weave" // Custom struct tag for Weaviate
 
 	// Comment markers
-	weaviateMarker       = "+" + weaviateTag              // Marks a struct to be included in Weaviate schema
-	weaviateDescMarker   = "+" + weaviateTag + ":desc:"   // Provides a description for the Weaviate class
-	weaviateConfigMarker = "+" + weaviateTag + ":config:" // Provides configuration for the Weaviate class
+	weaviateMarker            = "+" + weaviateTag                   // Marks a struct to be included in Weaviate schema
+	weaviateDescMarker        = "+" + weaviateTag + ":desc:"        // Provides a description for the Weaviate class
+	weaviateConfigMarker      = "+" + weaviateTag + ":config:"      // Provides configuration for the Weaviate class
+	weaviatePropMarker        = "+" + weaviateTag + ":prop:"        // Provides weave tag options in a field's comments
+	weaviateOwnerMarker       = "+" + weaviateTag + ":owner:"       // Names the team owning the Weaviate class
+	weaviateReadOnlyMarker    = "+" + weaviateTag + ":readonly"     // Generates only the read operations of the class
+	weaviateRenamedFromMarker = "+" + weaviateTag + ":renamedFrom:" // Names the class the Weaviate class was renamed from
+	weaviateBackfillMarker    = "+" + weaviateTag + ":backfill:"    // Registers a function as a backfill of its class
 )
 
+// validTokenizations lists the tokenization methods Weaviate accepts for text properties
+var validTokenizations = []string{"word", "lowercase", "whitespace", "field", "trigram", "gse", "kagome_kr"}
+
 // WeaviateClass represents a Weaviate class schema definition
 type WeaviateClass struct {
 	Package             string                 `json:"-"`
@@ -36,216 +55,794 @@ type WeaviateClass struct {
 	ShardingConfig      map[string]interface{} `json:"shardingConfig,omitempty"`
 	ReplicationConfig   map[string]interface{} `json:"replicationConfig,omitempty"`
 	InvertedIndexConfig map[string]interface{} `json:"invertedIndexConfig,omitempty"`
+	MultiTenancyConfig  map[string]interface{} `json:"multiTenancyConfig,omitempty"`
+	VectorConfig        map[string]interface{} `json:"vectorConfig,omitempty"`
+
+	Pos token.Position `json:"-"` // Position of the Go type declaration
+
+	// Consistency levels the generated client uses for the class's reads by ID and writes,
+	// unless a call sets its own; they aren't part of the Weaviate schema
+	ReadConsistency  string `json:"-"`
+	WriteConsistency string `json:"-"`
+
+	// Owner is the team owning the class, set with a +weave:owner: marker
+	Owner string `json:"-"`
+
+	// ReadOnly is set by a +weave:readonly marker for classes another pipeline writes;
+	// their generated CRUD has no write operations
+	ReadOnly bool `json:"-"`
+
+	// RenamedFrom is the former name of the class, set with a +weave:renamedFrom: marker.
+	// Weaviate can't rename classes, so weave migrate copies the objects of the old class.
+	RenamedFrom string `json:"-"`
+
+	// Passthrough holds the map fields tagged type=object, which the generated code writes
+	// and reads with whatever keys they have. Weaviate can't declare an object without nested
+	// properties, so they're left out of the schema for auto-schema to add on import.
+	Passthrough []WeaviateProperty `json:"-"`
+
+	// defaultVectorizer is set while Vectorizer holds the built-in default rather than a configured value
+	defaultVectorizer bool
 }
 
 // WeaviateProperty represents a property in a Weaviate class
 type WeaviateProperty struct {
-	Name            string   `json:"name"`
-	DataType        []string `json:"dataType"`
-	Description     string   `json:"description,omitempty"`
-	Tokenization    string   `json:"tokenization,omitempty"`
-	IndexFilterable bool     `json:"indexFilterable,omitempty"`
-	IndexSearchable bool     `json:"indexSearchable,omitempty"`
-	IndexInverted   bool     `json:"indexInverted,omitempty"`
+	Name         string   `json:"name"`
+	DataType     []string `json:"dataType"`
+	Description  string   `json:"description,omitempty"`
+	Tokenization string   `json:"tokenization,omitempty"`
+
+	// The indexes are nil unless set, leaving the choice to the server's defaults for the
+	// data type, so an explicit false is sent as such. IndexInverted is Weaviate's older
+	// setting for both, which it doesn't accept along with them.
+	IndexFilterable *bool `json:"indexFilterable,omitempty"`
+	IndexSearchable *bool `json:"indexSearchable,omitempty"`
+	IndexInverted   *bool `json:"indexInverted,omitempty"`
+
+	// IndexRangeFilters adds a range index serving GreaterThan and LessThan filters
+	IndexRangeFilters bool `json:"indexRangeFilters,omitempty"`
+
+	// ModuleConfig holds per-module settings, e.g. whether a vectorizer skips the property
+	ModuleConfig map[string]interface{} `json:"moduleConfig,omitempty"`
+
+	// NestedProperties are the fields of object and object[] properties
+	NestedProperties []WeaviateProperty `json:"nestedProperties,omitempty"`
+
+	JSON    bool   `json:"-"` // Stored as JSON text, encoded and decoded by the generated code
+	GoField string `json:"-"` // Go struct field the property was generated from
+	GoType  string `json:"-"` // Go type expression of that field
+	Enum    string `json:"-"` // Go enum type name, for string enums
+
+	// JSONKey is the key encoding/json writes the field as, when it isn't the property's
+	// name: the Go field name of a field without a json name, named by the naming strategy
+	JSONKey string `json:"-"`
+
+	// Version marks the integer property the generated Update checks and increments
+	// for optimistic concurrency, set with the version tag option
+	Version bool `json:"-"`
+
+	// Deprecated is set by the deprecated tag option for a property being retired: it stays
+	// in the schema and is still read, but the generated code no longer writes it.
+	// DeprecationNote is the option's value, e.g. what replaces the property.
+	Deprecated      bool   `json:"-"`
+	DeprecationNote string `json:"-"`
+
+	// RenamedFrom is the former name of the property, set with the renamedFrom tag option;
+	// weave migrate moves the values of the old property to it
+	RenamedFrom string `json:"-"`
+
+	Pos token.Position `json:"-"` // Position of the Go struct field
+}
+
+// fieldKey returns the JSON key of the property's Go field
+func (p WeaviateProperty) fieldKey() string {
+	if p.JSONKey != "" {
+		return p.JSONKey
+	}
+	return p.Name
 }
 
 // WeaviateSchemaDefinition represents the entire schema
 type WeaviateSchemaDefinition struct {
 	Classes []WeaviateClass `json:"classes"`
+	Enums   []Enum          `json:"-"`
+
+	// SourceHash is the SHA-256 of the Go sources the schema was generated from
+	SourceHash string `json:"-"`
+
+	// Metadata is set when OutputConfig.Metadata asks for it
+	Metadata *Metadata `json:"x-weave,omitempty"`
+
+	// Owners maps the classes with an owner to it, filled in by ToJSON so diffs of the
+	// JSON can name the owning teams
+	Owners map[string]string `json:"x-weave-owners,omitempty"`
+
+	// Deprecated maps classes to their deprecated properties and the notes of those, filled
+	// in by ToJSON so diffs of the JSON can mark them
+	Deprecated map[string]map[string]string `json:"x-weave-deprecated,omitempty"`
+
+	// Renamed maps classes to their renamed properties and the former names of those,
+	// filled in by ToJSON so diffs of the JSON report renames rather than a drop and an add
+	Renamed map[string]map[string]string `json:"x-weave-renamed,omitempty"`
+
+	// Backfills are the functions marked with +weave:backfill:, registered by the generated code
+	Backfills []Backfill `json:"-"`
+}
+
+// classOwners maps the classes with an owner to it
+func (s *WeaviateSchemaDefinition) classOwners() map[string]string {
+	var owners map[string]string
+	for _, class := range s.Classes {
+		if class.Owner == "" {
+			continue
+		}
+		if owners == nil {
+			owners = make(map[string]string)
+		}
+		owners[class.Class] = class.Owner
+	}
+	return owners
+}
+
+// deprecatedProperties maps the classes with deprecated properties to those and their notes
+func (s *WeaviateSchemaDefinition) deprecatedProperties() map[string]map[string]string {
+	var deprecated map[string]map[string]string
+	for _, class := range s.Classes {
+		for _, prop := range class.Properties {
+			if !prop.Deprecated {
+				continue
+			}
+			if deprecated == nil {
+				deprecated = make(map[string]map[string]string)
+			}
+			if deprecated[class.Class] == nil {
+				deprecated[class.Class] = make(map[string]string)
+			}
+			deprecated[class.Class][prop.Name] = prop.DeprecationNote
+		}
+	}
+	return deprecated
+}
+
+// renamedProperties maps the classes with renamed properties to those and their former names
+func (s *WeaviateSchemaDefinition) renamedProperties() map[string]map[string]string {
+	var renamed map[string]map[string]string
+	for _, class := range s.Classes {
+		for _, prop := range class.Properties {
+			if prop.RenamedFrom == "" {
+				continue
+			}
+			if renamed == nil {
+				renamed = make(map[string]map[string]string)
+			}
+			if renamed[class.Class] == nil {
+				renamed[class.Class] = make(map[string]string)
+			}
+			renamed[class.Class][prop.Name] = prop.RenamedFrom
+		}
+	}
+	return renamed
+}
+
+// usesEnum reports whether any property is backed by the named enum
+func (s *WeaviateSchemaDefinition) usesEnum(name string) bool {
+	for _, class := range s.Classes {
+		for _, prop := range class.Properties {
+			if prop.Enum == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// class returns the class with the given name
+func (s *WeaviateSchemaDefinition) class(name string) (WeaviateClass, bool) {
+	for _, class := range s.Classes {
+		if class.Class == name {
+			return class, true
+		}
+	}
+	return WeaviateClass{}, false
+}
+
+// FilterClasses keeps the classes named in include, or every class when it's empty, minus
+// the ones named in exclude, along with the enums they use. Naming a class the schema
+// doesn't have is an error, so typos don't silently select nothing.
+func (s *WeaviateSchemaDefinition) FilterClasses(include, exclude []string) error {
+	for _, name := range slices.Concat(include, exclude) {
+		if _, ok := s.class(name); !ok {
+			return fmt.Errorf("unknown class %s", name)
+		}
+	}
+
+	s.Classes = slices.DeleteFunc(s.Classes, func(class WeaviateClass) bool {
+		return (len(include) > 0 && !slices.Contains(include, class.Class)) || slices.Contains(exclude, class.Class)
+	})
+	s.Enums = slices.DeleteFunc(s.Enums, func(enum Enum) bool {
+		return !s.usesEnum(enum.Name)
+	})
+	s.Backfills = slices.DeleteFunc(s.Backfills, func(backfill Backfill) bool {
+		_, ok := s.class(backfill.Class)
+		return !ok
+	})
+	return nil
+}
+
+// Packages splits the schema by the directory of the Go package declaring each class.
+// Generated code refers to the class types unqualified, so it's generated per package.
+func (s *WeaviateSchemaDefinition) Packages() map[string]*WeaviateSchemaDefinition {
+	packages := make(map[string]*WeaviateSchemaDefinition)
+	for _, class := range s.Classes {
+		dir := filepath.Dir(class.Pos.Filename)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &WeaviateSchemaDefinition{SourceHash: s.SourceHash, Metadata: s.Metadata}
+			packages[dir] = pkg
+		}
+		pkg.Classes = append(pkg.Classes, class)
+	}
+	for _, enum := range s.Enums {
+		if pkg, ok := packages[enum.Dir]; ok {
+			pkg.Enums = append(pkg.Enums, enum)
+		}
+	}
+	for _, backfill := range s.Backfills {
+		if pkg, ok := packages[filepath.Dir(backfill.Pos.Filename)]; ok {
+			pkg.Backfills = append(pkg.Backfills, backfill)
+		}
+	}
+	return packages
 }
 
 // ToJSON converts the schema to a JSON string
 func (s *WeaviateSchemaDefinition) ToJSON(pretty bool) ([]byte, error) {
+	out := *s
+	out.Owners = s.classOwners()
+	out.Deprecated = s.deprecatedProperties()
+	out.Renamed = s.renamedProperties()
 	if pretty {
-		return json.MarshalIndent(s, "", "  ")
+		return json.MarshalIndent(&out, "", "  ")
 	}
-	return json.Marshal(s)
+	return json.Marshal(&out)
 }
 
-// GenerateWeaviateSchema processes Go source files and generates Weaviate schema
+// GenerateWeaviateSchema processes Go source files and generates Weaviate schema,
+// failing if any problem is reported as an error
 func GenerateWeaviateSchema(srcDir string) (*WeaviateSchemaDefinition, error) {
+	schema, diags, err := GenerateWeaviateSchemaWithConfig(srcDir, &Config{})
+	if err != nil {
+		return nil, err
+	}
+	if err := diags.Err(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// GenerateWeaviateSchemaWithConfig processes Go source files and generates Weaviate schema,
+// applying the project-level settings from cfg.
+//
+// Problems with individual files, structs or fields don't stop generation: the affected
+// item is skipped and reported in the returned diagnostics, so the schema holds everything
+// that could be generated. The error is reserved for failures that prevent any output.
+func GenerateWeaviateSchemaWithConfig(srcDir string, cfg *Config) (*WeaviateSchemaDefinition, Diagnostics, error) {
+	return GenerateWeaviateSchemaFromSources([]string{srcDir}, cfg)
+}
+
+// GenerateWeaviateSchemaFromSources is GenerateWeaviateSchemaWithConfig for several
+// source directories, merged into one schema. A source ending in "/..." also scans the
+// packages below it, like the go tool's package patterns. Every package is processed on
+// its own; class names taken by more than one type are reported as errors.
+func GenerateWeaviateSchemaFromSources(srcs []string, cfg *Config) (*WeaviateSchemaDefinition, Diagnostics, error) {
 	schema := &WeaviateSchemaDefinition{
 		Classes: []WeaviateClass{},
 	}
 
+	var target WeaviateVersion
+	if cfg.WeaviateVersion != "" {
+		var err error
+		if target, err = ParseWeaviateVersion(cfg.WeaviateVersion); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := cfg.Naming.validate(); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.Defaults.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	dirs, err := expandSourcePatterns(srcs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Set up the file set
 	fset := token.NewFileSet()
 
-	// Process files in the directory
-	err := processGoFiles(srcDir, fset, schema)
-	if err != nil {
-		return nil, err
+	// Process the files of each package
+	var diags Diagnostics
+	hash := sha256.New()
+	for _, dir := range dirs {
+		if len(dirs) > 1 {
+			fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(dir))
+		}
+		if err := processGoFiles(dir, fset, cfg, schema, hash, &diags); err != nil {
+			return nil, diags, err
+		}
+	}
+	schema.SourceHash = hex.EncodeToString(hash.Sum(nil))
+	if cfg.Output.Metadata {
+		schema.Metadata = newMetadata(dirs, schema.SourceHash)
 	}
 
-	return schema, nil
+	schema.Classes = dropDuplicateClasses(schema.Classes, &diags)
+	checkRenames(schema.Classes, &diags)
+	checkReferenceIDs(schema.Classes, &diags)
+	schema.Backfills = checkBackfills(schema.Backfills, schema.Classes, &diags)
+
+	for i := range schema.Classes {
+		cfg.Defaults.applyDefaults(&schema.Classes[i])
+	}
+	cfg.applyEnvironment(schema.Classes, &diags)
+
+	if cfg.WeaviateVersion != "" {
+		checkTargetVersion(schema, target, &diags)
+	}
+
+	return schema, diags, nil
 }
 
-// processGoFiles processes Go files in a directory
-func processGoFiles(dir string, fset *token.FileSet, schema *WeaviateSchemaDefinition) error {
+// expandSourcePatterns turns source directories and "dir/..." patterns into the list of
+// package directories to scan, without duplicates
+func expandSourcePatterns(srcs []string) ([]string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, src := range srcs {
+		root, recursive := strings.CutSuffix(filepath.ToSlash(src), "...")
+		if !recursive {
+			info, err := os.Stat(src)
+			if err != nil {
+				return nil, fmt.Errorf("error reading source directory: %v", err)
+			}
+			if !info.IsDir() {
+				return nil, fmt.Errorf("source %s is not a directory", src)
+			}
+			add(src)
+			continue
+		}
+
+		if root = strings.TrimSuffix(root, "/"); root == "" {
+			root = "."
+		}
+		err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			// Skip the directories the go tool ignores
+			name := d.Name()
+			if path != filepath.FromSlash(root) && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			if goFiles, _ := filepath.Glob(filepath.Join(path, "*.go")); len(goFiles) > 0 {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error expanding source pattern %s: %v", src, err)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no Go packages match %s", strings.Join(srcs, " "))
+	}
+	return dirs, nil
+}
+
+// dropDuplicateClasses reports classes whose name was already taken by another type,
+// in any file or package, and removes them, keeping the first declaration. Weaviate
+// capitalizes class names, so "article" and "Article" collide.
+func dropDuplicateClasses(classes []WeaviateClass, diags *Diagnostics) []WeaviateClass {
+	first := make(map[string]WeaviateClass, len(classes))
+	kept := classes[:0]
+	for _, class := range classes {
+		key := capitalize(class.Class)
+		if prev, ok := first[key]; ok {
+			diags.add(SeverityError, class.Pos, "class %s collides with class %s declared at %s", class.Class, prev.Class, prev.Pos)
+			continue
+		}
+		first[key] = class
+		kept = append(kept, class)
+	}
+	return kept
+}
+
+// checkReferenceIDs warns about references the generated code can't write: a field holding
+// the struct of a class without an ID property has no ID to send, so Encode leaves the
+// reference out. Ref[T] fields carry their own ID and are fine.
+func checkReferenceIDs(classes []WeaviateClass, diags *Diagnostics) {
+	for _, class := range classes {
+		for _, prop := range class.Properties {
+			if len(prop.DataType) != 1 || !isReferenceType(prop.DataType[0]) {
+				continue
+			}
+			elemType := strings.TrimPrefix(strings.TrimPrefix(prop.GoType, "[]"), "*")
+			if strings.HasPrefix(elemType, "Ref[") {
+				continue
+			}
+			i := slices.IndexFunc(classes, func(c WeaviateClass) bool { return c.Class == prop.DataType[0] })
+			if i < 0 {
+				continue
+			}
+			if _, ok := idProperty(classes[i]); !ok {
+				diags.add(SeverityWarning, prop.Pos, "field %s.%s references %s, which has no ID property, so the reference is never written; add an id field to %s or hold the reference as Ref[%s]", class.Class, prop.GoField, prop.DataType[0], prop.DataType[0], prop.DataType[0])
+			}
+		}
+	}
+}
+
+// checkRenames reports renamedFrom markers weave migrate can't follow: naming the class
+// itself, a class that's still generated, or a class another one was renamed from too
+func checkRenames(classes []WeaviateClass, diags *Diagnostics) {
+	renamed := make(map[string]string)
+	for _, class := range classes {
+		from := class.RenamedFrom
+		switch {
+		case from == "":
+			continue
+		case from == class.Class:
+			diags.add(SeverityError, class.Pos, "class %s is renamed from itself", class.Class)
+		case slices.ContainsFunc(classes, func(c WeaviateClass) bool { return c.Class == from }):
+			diags.add(SeverityError, class.Pos, "class %s is renamed from %s, which is still generated", class.Class, from)
+		case renamed[from] != "":
+			diags.add(SeverityError, class.Pos, "classes %s and %s are both renamed from %s", renamed[from], class.Class, from)
+		default:
+			renamed[from] = class.Class
+		}
+	}
+}
+
+// capitalize upper-cases the first letter of a name
+func capitalize(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// processGoFiles processes the Go files in a directory that aren't generated, adding their
+// contents to hash
+func processGoFiles(dir string, fset *token.FileSet, cfg *Config, schema *WeaviateSchemaDefinition, hash io.Writer, diags *Diagnostics) error {
 	// Read the directory
-	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
 	if err != nil {
 		return fmt.Errorf("error reading directory %s: %v", dir, err)
 	}
 
-	// Process each file/directory
-	for _, path := range files {
-		// Parse the Go file
-		goFile, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	// Parse every file first so package-wide declarations are known before structs are processed
+	var files []*ast.File
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("error parsing file %s: %v", path, err)
+			return fmt.Errorf("error reading file %s: %v", path, err)
+		}
+		goFile, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err == nil && ast.IsGenerated(goFile) {
+			// Generated files, weave's own among them, are output rather than models
+			continue
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.Base(path), len(src))
+		hash.Write(src)
+
+		if err != nil {
+			// Report every syntax error and skip the file
+			if list, ok := err.(scanner.ErrorList); ok {
+				for _, e := range list {
+					diags.add(SeverityError, e.Pos, "%s", e.Msg)
+				}
+			} else {
+				diags.add(SeverityError, token.Position{Filename: path}, "error parsing file: %v", err)
+			}
+			continue
 		}
+		files = append(files, goFile)
+	}
+
+	enums := collectEnums(files)
 
-		// Process the file's AST to find structs
-		if err := processFileAST(goFile, fset, schema); err != nil {
-			return err
+	scopes := make([]*fileScope, len(files))
+	for i, goFile := range files {
+		scopes[i] = newFileScope(goFile, fset, enums, cfg.Types, diags)
+		scopes[i].naming = cfg.Naming
+	}
+
+	// Marked aliases and instantiations may refer to structs declared in any file
+	structs := collectStructs(files, scopes)
+	classes := collectClasses(files)
+	nesting := make(map[*ast.StructType]bool)
+	for _, scope := range scopes {
+		scope.structs = structs
+		scope.classes = classes
+		scope.nesting = nesting
+	}
+
+	// Process each file's AST to find structs
+	packageClasses := len(schema.Classes)
+	for i, goFile := range files {
+		processFileAST(goFile, scopes[i], schema)
+	}
+	for i, goFile := range files {
+		schema.Backfills = append(schema.Backfills, collectBackfills(goFile, scopes[i], schema.Classes[packageClasses:])...)
+	}
+
+	// Keep the enums referenced by the package's classes for code generation
+	pkg := &WeaviateSchemaDefinition{Classes: schema.Classes[packageClasses:]}
+	for _, name := range slices.Sorted(maps.Keys(enums)) {
+		if pkg.usesEnum(name) {
+			enum := *enums[name]
+			enum.Dir = dir
+			schema.Enums = append(schema.Enums, enum)
 		}
 	}
 
 	return nil
 }
 
-// processStruct converts a Go struct into a Weaviate class
-func processStruct(packageName, structName string, structType *ast.StructType) (*WeaviateClass, error) {
+// processStruct converts a Go struct into a Weaviate class, skipping fields it can't convert
+func processStruct(scope *fileScope, packageName, structName string, structType *ast.StructType) *WeaviateClass {
 	class := &WeaviateClass{
 		Package:    packageName,
 		Class:      structName,
 		Properties: []WeaviateProperty{},
 		// Set default values for Weaviate schema
-		VectorIndexType: "hnsw",
-		Vectorizer:      "text2vec-contextionary",
+		VectorIndexType:   "hnsw",
+		Vectorizer:        "text2vec-contextionary",
+		defaultVectorizer: true,
 	}
 
-	// Process each field in the struct
-	for _, field := range structType.Fields.List {
-		// Skip embedded or unnamed fields
-		if len(field.Names) == 0 {
-			continue
-		}
-
-		fieldName := field.Names[0].Name
+	// Properties by lower-cased name, as encoding/json matches names case-insensitively
+	seen := make(map[string]WeaviateProperty)
+	var version string // Go field of the version property
 
-		// Skip unexported fields (starting with lowercase letter)
-		if !ast.IsExported(fieldName) {
-			continue
-		}
+	// Process each field encoding/json marshals, including those promoted from embedded
+	// structs, whose types resolve where the embedded struct is declared
+	for _, f := range jsonFields(scope, structName, structType) {
+		field, fieldName, scope := f.Field, f.name, f.scope
 
 		// Process field tags
-		var propName string
+		var jsonOpts jsonTag
 		var weaviateConfig map[string]string
 
 		if field.Tag != nil {
 			tagValue, err := strconv.Unquote(field.Tag.Value)
 			if err != nil {
-				return nil, fmt.Errorf("error unquoting struct tag: %v", err)
+				scope.errorf(field.Tag.Pos(), "malformed struct tag on field %s: %v", fieldName, err)
+				continue
 			}
 
-			propName = extractJSONFieldName(tagValue)
-			if propName == "-" {
+			jsonOpts = parseJSONTag(tagValue)
+			if jsonOpts.Skip {
+				continue
+			}
+			if jsonOpts.Name == "-" {
+				scope.errorf(field.Tag.Pos(), "field %s.%s is encoded as \"-\", which isn't a valid property name", structName, fieldName)
 				continue
 			}
 
-			weaviateConfig = extractWeaviateConfig(tagValue)
+			if weaviateConfig, err = extractWeaviateConfig(tagValue); err != nil {
+				scope.errorf(field.Tag.Pos(), "invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+				continue
+			}
 		}
 
-		// Use JSON name if available, otherwise use field name
-		if propName == "" {
-			propName = strings.ToLower(fieldName[:1]) + fieldName[1:]
+		// Field markers fill in the options the weave tag leaves out
+		markers, err := extractWeaviatePropMarkers(field.Doc, field.Comment)
+		if err != nil {
+			scope.errorf(field.Pos(), "invalid %s marker on field %s.%s: %v", weaviatePropMarker, structName, fieldName, err)
+			continue
 		}
-
-		var dataType []string
-		if weaviateConfig != nil {
-			if dt, ok := weaviateConfig["type"]; ok {
-				dataType = []string{dt}
-				delete(weaviateConfig, "type")
+		for key, value := range markers {
+			if weaviateConfig == nil {
+				weaviateConfig = make(map[string]string)
 			}
-		}
-
-		// Determine the data type
-		if dataType == nil {
-			d, err := determineWeaviateDataType(field.Type)
-			if err != nil {
-				return nil, fmt.Errorf("error determining data type for field %s: %v", fieldName, err)
+			if _, ok := weaviateConfig[key]; !ok {
+				weaviateConfig[key] = value
 			}
-			dataType = d
 		}
-
-		// Create the property
-		property := WeaviateProperty{
-			Name:     propName,
-			DataType: dataType,
+		optionsPos := field.Pos()
+		if field.Tag != nil {
+			optionsPos = field.Tag.Pos()
 		}
 
-		// Apply Weaviate-specific configurations from tags
-		if desc, ok := weaviateConfig["description"]; ok {
-			property.Description = desc
+		goType := types.ExprString(field.Type)
+		enum, isSlice := enumFieldType(field.Type, scope.enums)
+		f := propertyField{
+			structName: structName,
+			fieldName:  fieldName,
+			goType:     goType,
+			json:       jsonOpts,
+			options:    weaviateConfig,
+			isMap:      isMapType(field.Type),
+			direct:     !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "Optional["),
+			enumSlice:  isSlice,
+			dataType: func() ([]string, error) {
+				return determineWeaviateDataType(scope, field.Type)
+			},
 		}
-
-		if tokenization, ok := weaviateConfig["tokenization"]; ok {
-			property.Tokenization = tokenization
+		if enum != nil {
+			f.enum = enum.Name
+		}
+		property, kind, err := convertField(f, scope.naming, &version, func(format string, args ...interface{}) {
+			scope.warnf(optionsPos, format, args...)
+		})
+		if err != nil {
+			scope.errorf(optionsPos, "%v", err)
+			continue
+		}
+		if kind == fieldSkipped {
+			continue
 		}
+		property.Pos = scope.fset.Position(field.Pos())
 
-		if val, ok := weaviateConfig["indexFilterable"]; ok {
-			property.IndexFilterable = val == "true"
+		key := strings.ToLower(property.Name)
+		if prev, ok := seen[key]; ok {
+			scope.errorf(field.Pos(), "field %s.%s maps to property %s, colliding with field %s at %s", structName, fieldName, property.Name, prev.GoField, prev.Pos)
+			continue
+		}
+		if kind == fieldPassthrough {
+			seen[key] = property
+			class.Passthrough = append(class.Passthrough, property)
+			continue
 		}
 
-		if val, ok := weaviateConfig["indexSearchable"]; ok {
-			property.IndexSearchable = val == "true"
+		if property.DataType[0] == "object" || property.DataType[0] == "object[]" {
+			nested, ok := nestedProperties(scope, field, structName, fieldName)
+			if !ok {
+				continue
+			}
+			property.NestedProperties = nested
 		}
 
-		if val, ok := weaviateConfig["indexInverted"]; ok {
-			property.IndexInverted = val == "true"
+		// Descriptions fall back to the field's comments, which only source has
+		if property.Description == "" {
+			if desc := commentDescription(field.Doc); desc != "" {
+				property.Description = desc
+			} else {
+				property.Description = commentDescription(field.Comment)
+			}
 		}
 
+		seen[key] = property
 		class.Properties = append(class.Properties, property)
 	}
 
-	return class, nil
+	// A former name can't be taken by another property, or be the former name of two
+	renamed := make(map[string]string)
+	for _, prop := range class.Properties {
+		if prop.RenamedFrom == "" {
+			continue
+		}
+		if current, ok := seen[strings.ToLower(prop.RenamedFrom)]; ok {
+			scope.diags.add(SeverityError, prop.Pos, "field %s.%s is renamed from %s, which is the property of field %s", structName, prop.GoField, prop.RenamedFrom, current.GoField)
+		} else if other, ok := renamed[strings.ToLower(prop.RenamedFrom)]; ok {
+			scope.diags.add(SeverityError, prop.Pos, "fields %s.%s and %s.%s are both renamed from %s", structName, other, structName, prop.GoField, prop.RenamedFrom)
+		}
+		renamed[strings.ToLower(prop.RenamedFrom)] = prop.GoField
+	}
+
+	return class
 }
 
-// extractJSONFieldName extracts the field name from the json tag
-func extractJSONFieldName(tagValue string) string {
-	tags := reflect.StructTag(tagValue)
-	jsonTag := tags.Get("json")
-	if jsonTag == "" {
-		return ""
+// rangeFilterTypes are the data types Weaviate builds range indexes for
+var rangeFilterTypes = []string{"int", "number", "date"}
+
+// isMapType reports whether expr is a map, or a pointer to one
+func isMapType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
 	}
+	_, ok := expr.(*ast.MapType)
+	return ok
+}
 
-	parts := strings.Split(jsonTag, ",")
-	return parts[0]
+// boolOption converts the value of a boolean tag option to the pointer of a tri-state setting
+func boolOption(key, value string) (*bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s=%s isn't a boolean", key, value)
+	}
+	return &b, nil
 }
 
-// extractWeaviateConfig extracts Weaviate-specific configurations from the struct tag
-func extractWeaviateConfig(tagValue string) map[string]string {
-	config := make(map[string]string)
-	tags := reflect.StructTag(tagValue)
+// deprecationNote is the note of a deprecated tag option, which is "true" when it's a flag
+func deprecationNote(value string) string {
+	if value == "true" {
+		return ""
+	}
+	return value
+}
 
-	tag := tags.Get(weaviateTag)
-	if tag == "" {
-		return config
+// validateTokenization checks that the tokenization is known and only applied to text properties
+func validateTokenization(tokenization string, dataType []string) error {
+	if !slices.Contains(validTokenizations, tokenization) {
+		return fmt.Errorf("unknown tokenization %q (expected one of %s)", tokenization, strings.Join(validTokenizations, ", "))
 	}
 
-	parts := strings.Split(tag, ",")
-	for _, part := range parts {
-		keyVal := strings.Split(part, "=")
-		if len(keyVal) == 2 {
-			config[keyVal[0]] = keyVal[1]
+	if len(dataType) != 1 || (dataType[0] != "text" && dataType[0] != "text[]") {
+		return fmt.Errorf("tokenization %q only applies to text and text[] properties, not %s", tokenization, strings.Join(dataType, ","))
+	}
+
+	return nil
+}
+
+// propertyModuleConfig builds the moduleConfig of a property from its weave tag, given
+// either as JSON with moduleConfig={...} or option by option with
+// moduleConfig.<module>.<setting>=<value>, whose value is parsed as JSON when it's valid
+func propertyModuleConfig(config map[string]string) (map[string]interface{}, error) {
+	var moduleConfig map[string]interface{}
+	if value, ok := config["moduleConfig"]; ok {
+		if err := json.Unmarshal([]byte(value), &moduleConfig); err != nil {
+			return nil, fmt.Errorf("moduleConfig isn't a JSON object: %v", err)
 		}
 	}
 
-	return config
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		path, ok := strings.CutPrefix(key, "moduleConfig.")
+		if !ok {
+			continue
+		}
+		module, setting, ok := strings.Cut(path, ".")
+		if !ok || module == "" || setting == "" {
+			return nil, fmt.Errorf("%s should name a module and a setting, e.g. moduleConfig.text2vec-openai.skip", key)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(config[key]), &value); err != nil {
+			value = config[key]
+		}
+		if moduleConfig == nil {
+			moduleConfig = make(map[string]interface{})
+		}
+		settings, _ := moduleConfig[module].(map[string]interface{})
+		if settings == nil {
+			settings = make(map[string]interface{})
+			moduleConfig[module] = settings
+		}
+		settings[setting] = value
+	}
+
+	return moduleConfig, nil
 }
 
 // determineWeaviateDataType maps Go types to Weaviate data types
-func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
+func determineWeaviateDataType(scope *fileScope, expr ast.Expr) ([]string, error) {
 	switch t := expr.(type) {
 	case *ast.Ident:
+		// Registered types declared in the scanned package
+		if dataType, ok := scope.types.Lookup(t.Name); ok {
+			return []string{dataType}, nil
+		}
+
 		// Basic types
 		switch t.Name {
 		case "string":
 			return []string{"text"}, nil
-		// FIXME warn on uint types that won't fit in an int64?
-		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		case "int", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "byte", "rune":
+			return []string{"int"}, nil
+		case "uint", "uint64":
+			scope.warnf(t.Pos(), "%s values above the int64 range can't be stored in a Weaviate int", t.Name)
 			return []string{"int"}, nil
 		case "float16", "float32", "float64":
 			return []string{"number"}, nil
@@ -253,6 +850,17 @@ func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
 			return []string{"boolean"}, nil
 		}
 
+		// Helper types from weave types, unless the package declares its own
+		if dataType, ok := helperTypes[t.Name]; ok && isHelperType(scope, t.Name) {
+			return []string{dataType}, nil
+		}
+
+		// Structs of the package are references when marked as classes, and
+		// nested objects otherwise
+		if decl, ok := scope.structs[t.Name]; ok && decl.spec.TypeParams == nil && !scope.classes[t.Name] {
+			return []string{"object"}, nil
+		}
+
 		// Could be a custom type, enum, or reference to another class
 		if ast.IsExported(t.Name) {
 			// Likely a reference to another class
@@ -261,8 +869,13 @@ func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
 		return []string{"text"}, nil
 
 	case *ast.ArrayType:
+		// encoding/json writes byte slices as base64, the encoding of blobs
+		if ident, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (ident.Name == "byte" || ident.Name == "uint8") {
+			return []string{"blob"}, nil
+		}
+
 		// Array or slice type
-		elemType, err := determineWeaviateDataType(t.Elt)
+		elemType, err := determineWeaviateDataType(scope, t.Elt)
 		if err != nil {
 			return nil, err
 		}
@@ -285,22 +898,45 @@ func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
 
 	case *ast.StarExpr:
 		// Pointer type
-		return determineWeaviateDataType(t.X)
+		return determineWeaviateDataType(scope, t.X)
 
 	case *ast.SelectorExpr:
-		// Qualified identifier (e.g., time.Time)
-		if ident, ok := t.X.(*ast.Ident); ok {
-			if ident.Name == "time" && t.Sel.Name == "Time" {
-				return []string{"date"}, nil
+		// Qualified identifier (e.g., time.Time), resolved through the type registry
+		if name, ok := scope.qualifiedName(t); ok {
+			if dataType, ok := scope.lookupType(name); ok {
+				return []string{dataType}, nil
 			}
+		}
+
+		if ident, ok := t.X.(*ast.Ident); ok {
 			if ident.Name == "uuid" && t.Sel.Name == "UUID" {
 				return []string{"uuid"}, nil
 			}
 		}
 
 		// Default to "string" for other external types
+		scope.warnf(t.Pos(), "unknown type %s stored as text; register it in the types section of %s to map it", types.ExprString(t), DefaultConfigFile)
 		return []string{"text"}, nil
 
+	case *ast.IndexExpr:
+		// Ref[T] and Optional[T] from weave types
+		if ident, ok := t.X.(*ast.Ident); ok && isHelperType(scope, ident.Name) {
+			switch ident.Name {
+			case "Optional":
+				return determineWeaviateDataType(scope, t.Index)
+			case "Ref":
+				dataType, err := determineWeaviateDataType(scope, t.Index)
+				if err != nil {
+					return nil, err
+				}
+				if len(dataType) != 1 || !isReferenceType(dataType[0]) {
+					return nil, fmt.Errorf("%s doesn't reference a class", types.ExprString(t))
+				}
+				return dataType, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported generic type: %s", types.ExprString(t))
+
 	case *ast.StructType:
 		// Embedded struct - use "object" type in Weaviate
 		return []string{"object"}, nil
@@ -311,6 +947,7 @@ func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
 
 	case *ast.InterfaceType:
 		// Interface{} type - can be any type
+		scope.warnf(t.Pos(), "interface type stored as text")
 		return []string{"text"}, nil
 	}
 
@@ -318,7 +955,7 @@ func determineWeaviateDataType(expr ast.Expr) ([]string, error) {
 }
 
 // processFileAST processes the AST of a Go file to extract struct information for Weaviate schema
-func processFileAST(file *ast.File, fset *token.FileSet, schema *WeaviateSchemaDefinition) error {
+func processFileAST(file *ast.File, scope *fileScope, schema *WeaviateSchemaDefinition) {
 	packageName := file.Name.Name
 
 	for _, decl := range file.Decls {
@@ -333,12 +970,6 @@ func processFileAST(file *ast.File, fset *token.FileSet, schema *WeaviateSchemaD
 				continue
 			}
 
-			// Process struct type
-			structType, ok := typeSpec.Type.(*ast.StructType)
-			if !ok {
-				continue
-			}
-
 			// Check for marker comment that indicates this struct should be included in Weaviate
 			includeInWeaviate := hasWeaviateMarker(genDecl.Doc)
 			if !includeInWeaviate {
@@ -350,36 +981,84 @@ func processFileAST(file *ast.File, fset *token.FileSet, schema *WeaviateSchemaD
 				continue
 			}
 
+			// Resolve aliases and generic instantiations to the struct holding the fields
+			structType, structScope, err := resolveClassStruct(scope, typeSpec)
+			if err != nil {
+				scope.errorf(typeSpec.Pos(), "%v", err)
+				continue
+			}
+
 			// Extract description and config
 			description := extractWeaviateDescription(genDecl.Doc)
 			if description == "" {
 				description = extractWeaviateDescription(typeSpec.Doc)
 			}
+			if description == "" {
+				// Without a marker, the rest of the doc comment describes the class. The
+				// comment of a grouped declaration documents the group, not this type.
+				description = commentDescription(typeSpec.Doc)
+				if description == "" && !genDecl.Lparen.IsValid() {
+					description = commentDescription(genDecl.Doc)
+				}
+			}
+
+			owner := extractWeaviateOwner(genDecl.Doc)
+			if owner == "" {
+				owner = extractWeaviateOwner(typeSpec.Doc)
+			}
 
-			config := extractWeaviateClassConfig(genDecl.Doc)
+			renamedFrom := extractMarkerText(genDecl.Doc, weaviateRenamedFromMarker)
+			if renamedFrom == "" {
+				renamedFrom = extractMarkerText(typeSpec.Doc, weaviateRenamedFromMarker)
+			}
+
+			config := extractWeaviateClassConfig(scope, genDecl.Doc)
 			if len(config) == 0 {
-				config = extractWeaviateClassConfig(typeSpec.Doc)
+				config = extractWeaviateClassConfig(scope, typeSpec.Doc)
 			}
 
 			// Process the struct into a Weaviate class
-			class, err := processStruct(packageName, typeSpec.Name.Name, structType)
-			if err != nil {
-				return fmt.Errorf("error processing struct %s: %v", typeSpec.Name.Name, err)
-			}
+			class := processStruct(structScope, packageName, typeSpec.Name.Name, structType)
+			class.Pos = scope.fset.Position(typeSpec.Pos())
 
 			// Add description and config
 			if description != "" {
 				class.Description = description
 			}
+			class.Owner = owner
+			class.RenamedFrom = renamedFrom
+			class.ReadOnly = hasReadOnlyMarker(genDecl.Doc) || hasReadOnlyMarker(typeSpec.Doc)
 
 			// Apply configuration
+			for _, key := range slices.Sorted(maps.Keys(config)) {
+				if !slices.Contains(classConfigKeys, key) {
+					scope.warnf(typeSpec.Pos(), "unknown config key %q on class %s is ignored%s", key, class.Class, didYouMean(key, classConfigKeys))
+				}
+			}
+			for _, key := range slices.Sorted(maps.Keys(config)) {
+				if _, ok := classConfigFlags[key]; ok {
+					if _, ok := config[key].(bool); !ok {
+						scope.errorf(typeSpec.Pos(), "config key %s of class %s must be true or false, not %v", key, class.Class, config[key])
+					}
+				}
+			}
 			applyClassConfig(class, config)
+			if enabled, ok := class.MultiTenancyConfig["enabled"].(bool); ok && !enabled {
+				for _, key := range []string{"autoTenantCreation", "autoTenantActivation"} {
+					if class.MultiTenancyConfig[key] == true {
+						scope.errorf(typeSpec.Pos(), "class %s sets %s but disables multi-tenancy", class.Class, key)
+					}
+				}
+			}
+			for _, level := range []string{class.ReadConsistency, class.WriteConsistency} {
+				if level != "" && !slices.Contains(consistencyLevels, level) {
+					scope.errorf(typeSpec.Pos(), "class %s has unknown consistency level %q (expected one of %s)", class.Class, level, strings.Join(consistencyLevels, ", "))
+				}
+			}
 
 			schema.Classes = append(schema.Classes, *class)
 		}
 	}
-
-	return nil
 }
 
 // hasWeaviateMarker checks if the comment group contains a marker like "+weave"
@@ -398,16 +1077,41 @@ func hasWeaviateMarker(cg *ast.CommentGroup) bool {
 	return false
 }
 
+// hasReadOnlyMarker checks if the comment group contains a "+weave:readonly" line
+func hasReadOnlyMarker(cg *ast.CommentGroup) bool {
+	if cg == nil {
+		return false
+	}
+
+	for _, c := range cg.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == weaviateReadOnlyMarker {
+			return true
+		}
+	}
+
+	return false
+}
+
 // extractWeaviateDescription extracts the class description from comments
 func extractWeaviateDescription(cg *ast.CommentGroup) string {
+	return extractMarkerText(cg, weaviateDescMarker)
+}
+
+// extractWeaviateOwner extracts the owner from a marker like "+weave:owner: team-search"
+func extractWeaviateOwner(cg *ast.CommentGroup) string {
+	return extractMarkerText(cg, weaviateOwnerMarker)
+}
+
+// extractMarkerText returns the text following the first occurrence of marker
+func extractMarkerText(cg *ast.CommentGroup, marker string) string {
 	if cg == nil {
 		return ""
 	}
 
 	for _, c := range cg.List {
-		if strings.Contains(c.Text, weaviateDescMarker) {
-			// Extract the description that follows the marker
-			parts := strings.SplitN(c.Text, weaviateDescMarker, 2)
+		if strings.Contains(c.Text, marker) {
+			// Extract the text that follows the marker
+			parts := strings.SplitN(c.Text, marker, 2)
 			if len(parts) > 1 {
 				return strings.TrimSpace(parts[1])
 			}
@@ -417,21 +1121,61 @@ func extractWeaviateDescription(cg *ast.CommentGroup) string {
 	return ""
 }
 
-// extractWeaviateClassConfig extracts class-level configuration from comments
-func extractWeaviateClassConfig(cg *ast.CommentGroup) map[string]interface{} {
+// commentDescription returns the text of a doc or line comment as a single-line
+// description, without +weave marker lines and the YAML block of an empty config marker
+func commentDescription(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+
+	var words []string
+	inConfigBlock := false
+	for _, line := range strings.Split(cg.Text(), "\n") {
+		if inConfigBlock && strings.HasPrefix(line, " ") {
+			continue
+		}
+		inConfigBlock = false
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, weaviateMarker) {
+			inConfigBlock = trimmed == weaviateConfigMarker
+			continue
+		}
+		words = append(words, strings.Fields(trimmed)...)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// extractWeaviateClassConfig extracts class-level configuration from comments.
+//
+// The marker either carries inline key=value pairs, or is left empty and followed by
+// an indented YAML block:
+//
+//	// +weave:config:
+//	//   vectorizer: text2vec-openai
+//	//   moduleConfig:
+//	//     text2vec-openai:
+//	//       model: text-embedding-3-small
+func extractWeaviateClassConfig(scope *fileScope, cg *ast.CommentGroup) map[string]interface{} {
 	config := make(map[string]interface{})
 
 	if cg == nil {
 		return config
 	}
 
-	for _, c := range cg.List {
+	for i, c := range cg.List {
 		if strings.Contains(c.Text, weaviateConfigMarker) {
 			// Extract the configuration that follows the marker
 			parts := strings.SplitN(c.Text, weaviateConfigMarker, 2)
 			if len(parts) > 1 {
 				configStr := strings.TrimSpace(parts[1])
 
+				if configStr == "" {
+					parseConfigBlock(scope, c, configBlock(cg.List[i+1:]), config)
+					continue
+				}
+
 				// Parse the configuration string
 				// Format: key1=value1;key2=value2;...
 				configParts := strings.Split(configStr, ";")
@@ -470,6 +1214,145 @@ func extractWeaviateClassConfig(cg *ast.CommentGroup) map[string]interface{} {
 	return config
 }
 
+// configBlock returns the indented comment lines at the start of comments
+func configBlock(comments []*ast.Comment) []*ast.Comment {
+	for i, c := range comments {
+		text, ok := strings.CutPrefix(c.Text, "//")
+		if !ok || !(strings.HasPrefix(text, "  ") || strings.HasPrefix(text, "\t") || strings.HasPrefix(text, " \t")) {
+			return comments[:i]
+		}
+	}
+	return comments
+}
+
+// yamlErrorLine matches the line number in yaml.v3 error messages
+var yamlErrorLine = regexp.MustCompile(`line (\d+): `)
+
+// parseConfigBlock parses a YAML config block into config, reporting errors at the comment line they occur on
+func parseConfigBlock(scope *fileScope, marker *ast.Comment, block []*ast.Comment, config map[string]interface{}) {
+	if len(block) == 0 {
+		scope.errorf(marker.Pos(), "%s needs inline key=value pairs or an indented YAML block on the following lines", weaviateConfigMarker)
+		return
+	}
+
+	lines := make([]string, len(block))
+	for i, c := range block {
+		lines[i] = strings.TrimPrefix(c.Text, "//")
+	}
+
+	var blockConfig map[string]interface{}
+	if err := yaml.Unmarshal([]byte(dedent(lines)), &blockConfig); err != nil {
+		// Point at the offending comment line instead of the line within the block
+		pos := block[0].Pos()
+		msg := err.Error()
+		if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+			if line, _ := strconv.Atoi(m[1]); line >= 1 && line <= len(block) {
+				pos = block[line-1].Pos()
+				msg = strings.Replace(msg, m[0], "", 1)
+			}
+		}
+		scope.errorf(pos, "invalid %s block: %s", weaviateConfigMarker, msg)
+		return
+	}
+
+	for key, value := range blockConfig {
+		config[key] = value
+	}
+}
+
+// dedent removes the indentation shared by all non-blank lines
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// classConfigKeys are the keys of +weave:config: markers applyClassConfig reads
+var classConfigKeys = []string{
+	"vectorIndexType", "vectorizer", "vectorIndexConfig", "moduleConfig", "shardingConfig",
+	"replicationConfig", "invertedIndexConfig", "multiTenancyConfig", "vectorConfig",
+	"readConsistency", "writeConsistency",
+	"indexTimestamps", "indexNullState", "indexPropertyLength",
+	"autoTenantCreation", "autoTenantActivation",
+}
+
+// classConfigFlags are the boolean keys of +weave:config: markers, set in the config object
+// they belong to, so e.g. timestamp filters don't need the whole invertedIndexConfig as JSON
+var classConfigFlags = map[string]string{
+	"indexTimestamps":      "invertedIndexConfig",
+	"indexNullState":       "invertedIndexConfig",
+	"indexPropertyLength":  "invertedIndexConfig",
+	"autoTenantCreation":   "multiTenancyConfig",
+	"autoTenantActivation": "multiTenancyConfig",
+}
+
+// propertyTagKeys are the options of weave tags and +weave:prop: markers, in their
+// canonical order; moduleConfig.<module>.<setting> options are accepted as well
+var propertyTagKeys = []string{
+	"type", "json", "version", "deprecated", "renamedFrom", "description", "tokenization",
+	"indexFilterable", "indexSearchable", "indexInverted", "indexRangeFilters",
+	"moduleConfig",
+}
+
+// didYouMean suggests the known key closest to a misspelled one, as a message suffix,
+// or returns "" when none is close
+func didYouMean(key string, known []string) string {
+	best, bestDistance := "", len(key)/3+1
+	for _, candidate := range known {
+		if strings.EqualFold(key, candidate) {
+			return fmt.Sprintf("; did you mean %q?", candidate)
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d <= bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// consistencyLevels are the replication consistency levels of reads and writes
+var consistencyLevels = []string{"ONE", "QUORUM", "ALL"}
+
 // applyClassConfig applies configuration to a Weaviate class
 func applyClassConfig(class *WeaviateClass, config map[string]interface{}) {
 	for key, value := range config {
@@ -481,6 +1364,7 @@ func applyClassConfig(class *WeaviateClass, config map[string]interface{}) {
 		case "vectorizer":
 			if strValue, ok := value.(string); ok {
 				class.Vectorizer = strValue
+				class.defaultVectorizer = false
 			}
 		case "vectorIndexConfig":
 			if mapValue, ok := value.(map[string]interface{}); ok {
@@ -502,6 +1386,48 @@ func applyClassConfig(class *WeaviateClass, config map[string]interface{}) {
 			if mapValue, ok := value.(map[string]interface{}); ok {
 				class.InvertedIndexConfig = mapValue
 			}
+		case "multiTenancyConfig":
+			if mapValue, ok := value.(map[string]interface{}); ok {
+				class.MultiTenancyConfig = mapValue
+			}
+		case "vectorConfig":
+			if mapValue, ok := value.(map[string]interface{}); ok {
+				class.VectorConfig = mapValue
+			}
+		case "readConsistency":
+			if strValue, ok := value.(string); ok {
+				class.ReadConsistency = strings.ToUpper(strValue)
+			}
+		case "writeConsistency":
+			if strValue, ok := value.(string); ok {
+				class.WriteConsistency = strings.ToUpper(strValue)
+			}
+		}
+	}
+
+	// Flags go into their config objects after those are set, so they aren't replaced
+	for _, key := range slices.Sorted(maps.Keys(classConfigFlags)) {
+		value, ok := config[key].(bool)
+		if !ok {
+			continue
+		}
+		switch classConfigFlags[key] {
+		case "invertedIndexConfig":
+			if class.InvertedIndexConfig == nil {
+				class.InvertedIndexConfig = make(map[string]interface{})
+			}
+			class.InvertedIndexConfig[key] = value
+		case "multiTenancyConfig":
+			if class.MultiTenancyConfig == nil {
+				class.MultiTenancyConfig = make(map[string]interface{})
+			}
+			class.MultiTenancyConfig[key] = value
+			// Tenants are only created or activated automatically for multi-tenant classes
+			if value {
+				if _, ok := class.MultiTenancyConfig["enabled"]; !ok {
+					class.MultiTenancyConfig["enabled"] = true
+				}
+			}
 		}
 	}
 }
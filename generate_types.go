@@ -2,9 +2,40 @@ package weave
 
 import "path/filepath"
 
+// typesFile is the file GenerateTypes writes the helper types to
+const typesFile = "weave_types.go"
+
+// helperTypes maps the helper types GenerateTypes declares to their Weaviate data types.
+// The generic Ref[T] and Optional[T] take the data type of T.
+var helperTypes = map[string]string{
+	"GeoCoordinates": "geoCoordinates",
+	"PhoneNumber":    "phoneNumber",
+}
+
+// isHelperType reports whether name refers to a helper type from GenerateTypes
+// rather than a type the package declares itself
+func isHelperType(scope *fileScope, name string) bool {
+	decl, declared := scope.structs[name]
+	return !declared || filepath.Base(scope.fset.Position(decl.spec.Pos()).Filename) == typesFile
+}
+
+// GenerateTypes writes the helper types to weave_types.go: GeoCoordinates and PhoneNumber
+// for the data types of the same name, Ref[T] for references and Optional[T] for properties
+// that may be absent
 func GenerateTypes(packageName string, outputDir string) error {
+	return GenerateTypesWithConfig(packageName, outputDir, &Config{})
+}
+
+// GenerateTypesWithConfig is GenerateTypes with the output settings from cfg
+func GenerateTypesWithConfig(packageName string, outputDir string, cfg *Config) error {
+	notice, err := cfg.Output.notice("")
+	if err != nil {
+		return err
+	}
+
 	templateData := TemplateData[struct{}]{
-		PackageName: packageName,
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
 	}
-	return generateFromTemplate("types", templateData, filepath.Join(outputDir, "weave_types.go"))
+	return generateFromTemplate("types", templateData, filepath.Join(outputDir, typesFile))
 }
@@ -2,7 +2,10 @@ module github.com/huffduff/weave
 
 go 1.23.0
 
-require github.com/urfave/cli/v3 v3.0.0-beta1
+require (
+	github.com/urfave/cli/v3 v3.0.0-beta1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
@@ -0,0 +1,60 @@
+package weave
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+// GraphFormat is an output format for the reference graph
+type GraphFormat string
+
+const (
+	GraphDOT     GraphFormat = "dot"     // Graphviz
+	GraphMermaid GraphFormat = "mermaid" // Mermaid flowchart
+)
+
+// GraphFormatForFile picks the graph format from a file extension:
+// .dot and .gv for Graphviz, .mmd and .mermaid for Mermaid
+func GraphFormatForFile(path string) (GraphFormat, error) {
+	switch filepath.Ext(path) {
+	case ".dot", ".gv":
+		return GraphDOT, nil
+	case ".mmd", ".mermaid":
+		return GraphMermaid, nil
+	}
+	return "", fmt.Errorf("can't tell the graph format of %s; use a .dot, .gv, .mmd or .mermaid extension", path)
+}
+
+// WriteGraph writes the classes as nodes and their references as edges labeled
+// with the property, one edge per referencing property
+func WriteGraph(w io.Writer, schema *WeaviateSchemaDefinition, format GraphFormat) error {
+	bw := bufio.NewWriter(w)
+
+	switch format {
+	case GraphDOT:
+		fmt.Fprintln(bw, "digraph weave {")
+		fmt.Fprintln(bw, "\tnode [shape=box];")
+		for _, class := range schema.Classes {
+			fmt.Fprintf(bw, "\t%s;\n", strconv.Quote(class.Class))
+		}
+		for _, ref := range schema.references() {
+			fmt.Fprintf(bw, "\t%s -> %s [label=%s];\n", strconv.Quote(ref.From), strconv.Quote(ref.To), strconv.Quote(ref.Property))
+		}
+		fmt.Fprintln(bw, "}")
+	case GraphMermaid:
+		fmt.Fprintln(bw, "flowchart LR")
+		for _, class := range schema.Classes {
+			fmt.Fprintf(bw, "    %s\n", class.Class)
+		}
+		for _, ref := range schema.references() {
+			fmt.Fprintf(bw, "    %s -->|%s| %s\n", ref.From, ref.Property, ref.To)
+		}
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,237 @@
+package weave
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/huffduff/weave/ir"
+)
+
+// IR returns the schema as the intermediate representation embedders post-process before
+// converting it back with SchemaFromIR. The IR shares no memory with the schema.
+func (s *WeaviateSchemaDefinition) IR() *ir.Schema {
+	out := &ir.Schema{
+		Version:    ir.Version,
+		Classes:    make([]ir.Class, len(s.Classes)),
+		SourceHash: s.SourceHash,
+	}
+	for i, class := range s.Classes {
+		out.Classes[i] = ir.Class{
+			Name:                class.Class,
+			Description:         class.Description,
+			Package:             class.Package,
+			Pos:                 irPosition(class.Pos),
+			Vectorizer:          class.Vectorizer,
+			DefaultVectorizer:   class.defaultVectorizer,
+			VectorIndexType:     class.VectorIndexType,
+			VectorIndexConfig:   copyConfig(class.VectorIndexConfig),
+			VectorConfig:        copyConfig(class.VectorConfig),
+			ModuleConfig:        copyConfig(class.ModuleConfig),
+			ShardingConfig:      copyConfig(class.ShardingConfig),
+			ReplicationConfig:   copyConfig(class.ReplicationConfig),
+			InvertedIndexConfig: copyConfig(class.InvertedIndexConfig),
+			MultiTenancyConfig:  copyConfig(class.MultiTenancyConfig),
+			Properties:          irProperties(class.Properties),
+			Passthrough:         irProperties(class.Passthrough),
+			ReadConsistency:     class.ReadConsistency,
+			WriteConsistency:    class.WriteConsistency,
+			Owner:               class.Owner,
+			ReadOnly:            class.ReadOnly,
+			RenamedFrom:         class.RenamedFrom,
+		}
+	}
+	for _, enum := range s.Enums {
+		values := make([]ir.EnumValue, len(enum.Values))
+		for i, value := range enum.Values {
+			values[i] = ir.EnumValue{Name: value.Name, Value: value.Value}
+		}
+		out.Enums = append(out.Enums, ir.Enum{Name: enum.Name, Values: values, Dir: enum.Dir})
+	}
+	for _, backfill := range s.Backfills {
+		out.Backfills = append(out.Backfills, ir.Backfill{
+			Name:  backfill.Name,
+			Func:  backfill.Func,
+			Class: backfill.Class,
+			Pos:   irPosition(backfill.Pos),
+		})
+	}
+	return out
+}
+
+// irProperties converts properties and their nested properties to the IR
+func irProperties(props []WeaviateProperty) []ir.Property {
+	if props == nil {
+		return nil
+	}
+	out := make([]ir.Property, len(props))
+	for i, prop := range props {
+		out[i] = ir.Property{
+			Name:              prop.Name,
+			DataType:          append([]string(nil), prop.DataType...),
+			Description:       prop.Description,
+			Tokenization:      prop.Tokenization,
+			IndexFilterable:   copyBool(prop.IndexFilterable),
+			IndexSearchable:   copyBool(prop.IndexSearchable),
+			IndexInverted:     copyBool(prop.IndexInverted),
+			IndexRangeFilters: prop.IndexRangeFilters,
+			ModuleConfig:      copyConfig(prop.ModuleConfig),
+			Nested:            irProperties(prop.NestedProperties),
+			Go:                ir.GoField{Name: prop.GoField, Type: prop.GoType, Enum: prop.Enum, Key: prop.JSONKey, JSON: prop.JSON},
+			Pos:               irPosition(prop.Pos),
+			Version:           prop.Version,
+			Deprecated:        prop.Deprecated,
+			DeprecationNote:   prop.DeprecationNote,
+			RenamedFrom:       prop.RenamedFrom,
+		}
+	}
+	return out
+}
+
+// SchemaFromIR converts the intermediate representation back into a schema for the
+// generators, checking that it's of this Version and that classes and properties are named
+// and typed. An empty Version is taken as the current one.
+func SchemaFromIR(model *ir.Schema) (*WeaviateSchemaDefinition, error) {
+	if model.Version != "" && model.Version != ir.Version {
+		return nil, fmt.Errorf("unsupported IR version %s (expected %s)", model.Version, ir.Version)
+	}
+
+	schema := &WeaviateSchemaDefinition{
+		Classes:    make([]WeaviateClass, len(model.Classes)),
+		SourceHash: model.SourceHash,
+	}
+	names := make(map[string]bool, len(model.Classes))
+	for i, class := range model.Classes {
+		if class.Name == "" {
+			return nil, fmt.Errorf("class %d has no name", i)
+		}
+		if names[strings.ToLower(class.Name)] {
+			return nil, fmt.Errorf("class %s is defined twice", class.Name)
+		}
+		names[strings.ToLower(class.Name)] = true
+
+		props, err := propertiesFromIR(class.Properties, class.Name)
+		if err != nil {
+			return nil, err
+		}
+		passthrough, err := propertiesFromIR(class.Passthrough, class.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema.Classes[i] = WeaviateClass{
+			Class:               class.Name,
+			Description:         class.Description,
+			Package:             class.Package,
+			Pos:                 tokenPosition(class.Pos),
+			Vectorizer:          class.Vectorizer,
+			defaultVectorizer:   class.DefaultVectorizer,
+			VectorIndexType:     class.VectorIndexType,
+			VectorIndexConfig:   copyConfig(class.VectorIndexConfig),
+			VectorConfig:        copyConfig(class.VectorConfig),
+			ModuleConfig:        copyConfig(class.ModuleConfig),
+			ShardingConfig:      copyConfig(class.ShardingConfig),
+			ReplicationConfig:   copyConfig(class.ReplicationConfig),
+			InvertedIndexConfig: copyConfig(class.InvertedIndexConfig),
+			MultiTenancyConfig:  copyConfig(class.MultiTenancyConfig),
+			Properties:          props,
+			Passthrough:         passthrough,
+			ReadConsistency:     class.ReadConsistency,
+			WriteConsistency:    class.WriteConsistency,
+			Owner:               class.Owner,
+			ReadOnly:            class.ReadOnly,
+			RenamedFrom:         class.RenamedFrom,
+		}
+		if schema.Classes[i].Properties == nil {
+			schema.Classes[i].Properties = []WeaviateProperty{}
+		}
+	}
+	for _, enum := range model.Enums {
+		values := make([]EnumValue, len(enum.Values))
+		for i, value := range enum.Values {
+			values[i] = EnumValue{Name: value.Name, Value: value.Value}
+		}
+		schema.Enums = append(schema.Enums, Enum{Name: enum.Name, Values: values, Dir: enum.Dir})
+	}
+	for _, backfill := range model.Backfills {
+		if !names[strings.ToLower(backfill.Class)] {
+			return nil, fmt.Errorf("backfill %s fills class %s, which isn't in the schema", backfill.Name, backfill.Class)
+		}
+		schema.Backfills = append(schema.Backfills, Backfill{
+			Name:  backfill.Name,
+			Func:  backfill.Func,
+			Class: backfill.Class,
+			Pos:   tokenPosition(backfill.Pos),
+		})
+	}
+	return schema, nil
+}
+
+// propertiesFromIR converts properties and their nested properties from the IR
+func propertiesFromIR(props []ir.Property, path string) ([]WeaviateProperty, error) {
+	if props == nil {
+		return nil, nil
+	}
+	out := make([]WeaviateProperty, len(props))
+	for i, prop := range props {
+		if prop.Name == "" {
+			return nil, fmt.Errorf("property %d of %s has no name", i, path)
+		}
+		if len(prop.DataType) == 0 {
+			return nil, fmt.Errorf("property %s.%s has no data type", path, prop.Name)
+		}
+		nested, err := propertiesFromIR(prop.Nested, path+"."+prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = WeaviateProperty{
+			Name:              prop.Name,
+			DataType:          append([]string(nil), prop.DataType...),
+			Description:       prop.Description,
+			Tokenization:      prop.Tokenization,
+			IndexFilterable:   copyBool(prop.IndexFilterable),
+			IndexSearchable:   copyBool(prop.IndexSearchable),
+			IndexInverted:     copyBool(prop.IndexInverted),
+			IndexRangeFilters: prop.IndexRangeFilters,
+			ModuleConfig:      copyConfig(prop.ModuleConfig),
+			NestedProperties:  nested,
+			GoField:           prop.Go.Name,
+			GoType:            prop.Go.Type,
+			Enum:              prop.Go.Enum,
+			JSONKey:           prop.Go.Key,
+			JSON:              prop.Go.JSON,
+			Pos:               tokenPosition(prop.Pos),
+			Version:           prop.Version,
+			Deprecated:        prop.Deprecated,
+			DeprecationNote:   prop.DeprecationNote,
+			RenamedFrom:       prop.RenamedFrom,
+		}
+	}
+	return out, nil
+}
+
+// irPosition converts a source position to the IR
+func irPosition(pos token.Position) ir.Position {
+	return ir.Position{File: pos.Filename, Line: pos.Line, Column: pos.Column}
+}
+
+// tokenPosition converts a source position from the IR
+func tokenPosition(pos ir.Position) token.Position {
+	return token.Position{Filename: pos.File, Line: pos.Line, Column: pos.Column}
+}
+
+// copyBool copies an optional setting
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := *b
+	return &v
+}
+
+// copyConfig deep-copies a settings map, keeping nil as nil
+func copyConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	return copyConfigValue(config).(map[string]interface{})
+}
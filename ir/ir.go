@@ -0,0 +1,173 @@
+// Package ir is weave's intermediate representation: the classes parsed from Go sources,
+// with their Weaviate settings and the Go types behind them, as handed to the generators.
+// Embedders post-process it between parsing and emission:
+//
+//	schema, diags, err := weave.GenerateWeaviateSchemaFromSources(srcs, cfg)
+//	model := schema.IR()
+//	model.Classes = append(model.Classes, auditClass)
+//	schema, err = weave.SchemaFromIR(model)
+//
+// The types only change compatibly within a Version; fields are added, never renamed or
+// removed. Everything is JSON-serializable, and Schema carries its Version.
+package ir
+
+import "unicode"
+
+// Version is the version of the representation, written to Schema.Version
+const Version = "v1"
+
+// Schema is the model of every class generated from the sources
+type Schema struct {
+	// Version is the Version the schema was built with
+	Version string `json:"version"`
+
+	Classes []Class `json:"classes"`
+
+	// Enums are the string enums backing properties, see Property.Go
+	Enums []Enum `json:"enums,omitempty"`
+
+	// Backfills are the functions marked with +weave:backfill:
+	Backfills []Backfill `json:"backfills,omitempty"`
+
+	// SourceHash is the SHA-256 of the Go sources the schema was parsed from
+	SourceHash string `json:"sourceHash,omitempty"`
+}
+
+// Position is a place in the Go sources; Line is 0 when it isn't known
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// Class is a Weaviate class and the Go struct it was generated from, whose name it shares
+type Class struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Package is the name of the Go package declaring the struct
+	Package string   `json:"package"`
+	Pos     Position `json:"pos"`
+
+	// Vectorizer is the class's vectorizer module; DefaultVectorizer is set while it holds
+	// the module Weaviate picks by default rather than a configured one
+	Vectorizer        string `json:"vectorizer,omitempty"`
+	DefaultVectorizer bool   `json:"defaultVectorizer,omitempty"`
+
+	// The class settings, as Weaviate's class object has them
+	VectorIndexType     string                 `json:"vectorIndexType,omitempty"`
+	VectorIndexConfig   map[string]interface{} `json:"vectorIndexConfig,omitempty"`
+	VectorConfig        map[string]interface{} `json:"vectorConfig,omitempty"`
+	ModuleConfig        map[string]interface{} `json:"moduleConfig,omitempty"`
+	ShardingConfig      map[string]interface{} `json:"shardingConfig,omitempty"`
+	ReplicationConfig   map[string]interface{} `json:"replicationConfig,omitempty"`
+	InvertedIndexConfig map[string]interface{} `json:"invertedIndexConfig,omitempty"`
+	MultiTenancyConfig  map[string]interface{} `json:"multiTenancyConfig,omitempty"`
+
+	Properties []Property `json:"properties"`
+
+	// Passthrough are the map fields tagged type=object, which aren't in the Weaviate schema
+	Passthrough []Property `json:"passthrough,omitempty"`
+
+	// ReadConsistency and WriteConsistency are the consistency levels of the generated client
+	ReadConsistency  string `json:"readConsistency,omitempty"`
+	WriteConsistency string `json:"writeConsistency,omitempty"`
+
+	// Owner is the team owning the class, see +weave:owner:
+	Owner string `json:"owner,omitempty"`
+
+	// ReadOnly classes get no write operations, see +weave:readonly
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// RenamedFrom is the class's former name, see +weave:renamedFrom:
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+}
+
+// Property is a property of a class, or a nested property of an object property
+type Property struct {
+	Name         string   `json:"name"`
+	DataType     []string `json:"dataType"`
+	Description  string   `json:"description,omitempty"`
+	Tokenization string   `json:"tokenization,omitempty"`
+
+	// The indexes are nil unless set, leaving the choice to Weaviate's defaults
+	IndexFilterable   *bool `json:"indexFilterable,omitempty"`
+	IndexSearchable   *bool `json:"indexSearchable,omitempty"`
+	IndexInverted     *bool `json:"indexInverted,omitempty"`
+	IndexRangeFilters bool  `json:"indexRangeFilters,omitempty"`
+
+	ModuleConfig map[string]interface{} `json:"moduleConfig,omitempty"`
+
+	// Nested are the properties of object and object[] properties
+	Nested []Property `json:"nested,omitempty"`
+
+	// Go is the struct field the property was generated from
+	Go  GoField  `json:"go"`
+	Pos Position `json:"pos"`
+
+	// Version marks the property the generated Update checks for optimistic concurrency
+	Version bool `json:"version,omitempty"`
+
+	// Deprecated properties are read but no longer written; DeprecationNote says why
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	DeprecationNote string `json:"deprecationNote,omitempty"`
+
+	// RenamedFrom is the property's former name, see the renamedFrom tag option
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+}
+
+// GoField is the Go side of a property
+type GoField struct {
+	// Name is the struct field's name and Type its type expression, e.g. []string
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Enum names the string enum type of the field, if any
+	Enum string `json:"enum,omitempty"`
+
+	// Key is the JSON key encoding/json writes the field as, when it isn't the property's name
+	Key string `json:"key,omitempty"`
+
+	// JSON is set when the field is stored as JSON text
+	JSON bool `json:"json,omitempty"`
+}
+
+// Reference is a property of a class pointing at another class
+type Reference struct {
+	Property string `json:"property"`
+	Target   string `json:"target"`
+}
+
+// References lists the properties of the class referencing other classes, in property order
+func (c Class) References() []Reference {
+	var refs []Reference
+	for _, prop := range c.Properties {
+		for _, dataType := range prop.DataType {
+			if r := []rune(dataType); len(r) > 0 && unicode.IsUpper(r[0]) {
+				refs = append(refs, Reference{Property: prop.Name, Target: dataType})
+			}
+		}
+	}
+	return refs
+}
+
+// Enum is a defined string type with constants, declared in the package in Dir
+type Enum struct {
+	Name   string      `json:"name"`
+	Values []EnumValue `json:"values"`
+	Dir    string      `json:"dir"`
+}
+
+// EnumValue is a constant of an Enum
+type EnumValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Backfill is a function marked with +weave:backfill:, filling in Class's objects
+type Backfill struct {
+	Name  string   `json:"name"`
+	Func  string   `json:"func"`
+	Class string   `json:"class"`
+	Pos   Position `json:"pos"`
+}
@@ -0,0 +1,58 @@
+package weave
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/huffduff/weave/ir"
+)
+
+// clearOffsets drops the byte offsets of the positions in a schema, which the IR doesn't
+// carry: diagnostics only print files, lines and columns
+func clearOffsets(schema *WeaviateSchemaDefinition) {
+	var clearProperties func(props []WeaviateProperty)
+	clearProperties = func(props []WeaviateProperty) {
+		for i := range props {
+			props[i].Pos.Offset = 0
+			clearProperties(props[i].NestedProperties)
+		}
+	}
+	for i := range schema.Classes {
+		schema.Classes[i].Pos.Offset = 0
+		clearProperties(schema.Classes[i].Properties)
+		clearProperties(schema.Classes[i].Passthrough)
+	}
+	for i := range schema.Backfills {
+		schema.Backfills[i].Pos.Offset = 0
+	}
+}
+
+func TestSchemaFromIRRoundTrip(t *testing.T) {
+	schema := reflectSchema(t, NamingSnakeCase)
+	clearOffsets(schema)
+
+	got, err := SchemaFromIR(schema.IR())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, schema) {
+		t.Errorf("schema changed through the IR:\ngot:  %#v\nwant: %#v", got, schema)
+	}
+
+	// Embedders read and write the IR as JSON
+	data, err := json.Marshal(schema.IR())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model ir.Schema
+	if err := json.Unmarshal(data, &model); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = SchemaFromIR(&model); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, schema) {
+		t.Errorf("schema changed through the IR's JSON:\ngot:  %#v\nwant: %#v", got, schema)
+	}
+}
@@ -0,0 +1,253 @@
+package weave
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// jsonTag holds the parts of a json struct tag that affect how encoding/json
+// marshals a field, and so the property the field becomes
+type jsonTag struct {
+	Name      string // key of the field; empty when the tag doesn't set a valid one
+	Skip      bool   // json:"-"
+	OmitEmpty bool
+	String    bool // numbers and booleans are encoded as JSON strings
+}
+
+// parseJSONTag interprets the json key of a struct tag the way encoding/json does:
+// "-" skips the field while "-," names it "-", and an invalid name is ignored
+// so the field name is used instead
+func parseJSONTag(tagValue string) jsonTag {
+	tag, ok := reflect.StructTag(tagValue).Lookup("json")
+	if !ok {
+		return jsonTag{}
+	}
+	if tag == "-" {
+		return jsonTag{Skip: true}
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	parsed := jsonTag{}
+	if isValidJSONTagName(name) {
+		parsed.Name = name
+	}
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch opt {
+		case "omitempty":
+			parsed.OmitEmpty = true
+		case "string":
+			parsed.String = true
+		}
+	}
+	return parsed
+}
+
+// isValidJSONTagName mirrors the name check of encoding/json
+func isValidJSONTagName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// jsonCandidate is a field competing for its JSON key with the fields promoted from
+// embedded structs
+type jsonCandidate struct {
+	name   string // Go name of the field
+	key    string // JSON key
+	tagged bool   // the key comes from a json tag
+	depth  int    // levels of embedding the field is promoted through
+}
+
+// goName is a Go field name at a depth of embedding, which Go selectors resolve by
+type goName struct {
+	name  string
+	depth int
+}
+
+// dominantFields returns the indexes of the candidates encoding/json marshals, in order:
+// of the fields with the same key, the least embedded wins, then the one with a json name,
+// and fields that stay tied are left out and passed to tied. Generated code selects fields
+// by their Go name, so promoted fields hidden by another field of the same name in names,
+// the fields of every depth, are left out too and passed to hidden.
+func dominantFields(candidates []jsonCandidate, names []goName, tied, hidden func(i int)) []int {
+	byKey := make(map[string][]int)
+	for i, c := range candidates {
+		byKey[c.key] = append(byKey[c.key], i)
+	}
+
+	var fields []int
+	for i, c := range candidates {
+		group := byKey[c.key]
+		dominant, tie := group[0], false
+		for _, j := range group[1:] {
+			other := candidates[j]
+			switch best := candidates[dominant]; {
+			case other.depth < best.depth || other.depth == best.depth && other.tagged && !best.tagged:
+				dominant, tie = j, false
+			case other.depth == best.depth && other.tagged == best.tagged:
+				tie = true
+			}
+		}
+		switch {
+		case dominant != i:
+		case tie:
+			tied(i)
+		case c.depth > 0 && countNames(names, c.name, c.depth) > 1:
+			hidden(i)
+		default:
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+// countNames counts the fields named name at depth or less
+func countNames(names []goName, name string, depth int) int {
+	n := 0
+	for _, other := range names {
+		if other.name == name && other.depth <= depth {
+			n++
+		}
+	}
+	return n
+}
+
+// structField is a field encoding/json marshals for a struct: one of its own fields, or
+// a field promoted from an embedded struct
+type structField struct {
+	*ast.Field
+	name  string     // Go name of the field
+	scope *fileScope // scope the field's type resolves in
+}
+
+// jsonFields lists the fields encoding/json marshals for a struct declared in source, in
+// its order, with the fields of embedded structs without a json name promoted the way
+// dominantFields picks them
+func jsonFields(scope *fileScope, structName string, structType *ast.StructType) []structField {
+	w := fieldWalker{structName: structName, visited: make(map[*ast.StructType]bool)}
+	w.walk(scope, structType, 0)
+
+	indexes := dominantFields(w.candidates, w.names, func(i int) {
+		scope.warnf(w.fields[i].Pos(), "several fields of %s are encoded as %q at the same depth of embedding, so encoding/json leaves them all out", structName, w.candidates[i].key)
+	}, func(i int) {
+		name := w.candidates[i].name
+		scope.errorf(w.fields[i].Pos(), "field %s promoted into %s is hidden by another field named %s, so generated code can't select it", name, structName, name)
+	})
+	fields := make([]structField, len(indexes))
+	for i, index := range indexes {
+		fields[i] = w.fields[index]
+	}
+	return fields
+}
+
+// fieldWalker collects the fields of a struct and the structs it embeds
+type fieldWalker struct {
+	structName string
+	visited    map[*ast.StructType]bool
+	names      []goName
+	candidates []jsonCandidate
+	fields     []structField // the field of each candidate
+}
+
+// walk adds the fields of structType, promoted through depth levels of embedding
+func (w *fieldWalker) walk(scope *fileScope, structType *ast.StructType, depth int) {
+	if w.visited[structType] {
+		return
+	}
+	w.visited[structType] = true
+	defer delete(w.visited, structType)
+
+	for _, field := range structType.Fields.List {
+		var tag jsonTag
+		if field.Tag != nil {
+			if tagValue, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = parseJSONTag(tagValue)
+			}
+		}
+
+		if len(field.Names) > 0 {
+			for _, name := range field.Names {
+				w.names = append(w.names, goName{name: name.Name, depth: depth})
+				if !ast.IsExported(name.Name) || tag.Skip {
+					continue
+				}
+				w.add(scope, field, name.Name, tag, depth)
+			}
+			continue
+		}
+
+		// Embedded fields are named after their type
+		typeExpr := field.Type
+		if star, ok := typeExpr.(*ast.StarExpr); ok {
+			typeExpr = star.X
+		}
+		var typeName *ast.Ident
+		switch t := typeExpr.(type) {
+		case *ast.Ident:
+			typeName = t
+		case *ast.IndexExpr:
+			typeName, _ = t.X.(*ast.Ident)
+		case *ast.IndexListExpr:
+			typeName, _ = t.X.(*ast.Ident)
+		case *ast.SelectorExpr:
+			typeName = t.Sel
+		}
+		if typeName == nil {
+			continue
+		}
+		w.names = append(w.names, goName{name: typeName.Name, depth: depth})
+		if tag.Skip {
+			continue
+		}
+
+		var embedded *ast.StructType
+		var embeddedScope *fileScope
+		_, foreign := typeExpr.(*ast.SelectorExpr)
+		if !foreign {
+			embedded, embeddedScope, _ = resolveClassStruct(scope, &ast.TypeSpec{Name: typeName, Type: typeExpr})
+		}
+		switch {
+		case tag.Name != "" || embedded == nil && !foreign && scope.structs[typeName.Name] == nil:
+			// A json name, or a type that isn't a struct, makes it a field of its own
+			if ast.IsExported(typeName.Name) {
+				w.add(scope, field, typeName.Name, tag, depth)
+			}
+		case embedded == nil:
+			scope.warnf(field.Pos(), "fields of %s embedded in %s are left out of the schema, as only structs declared in this package can be flattened", types.ExprString(typeExpr), w.structName)
+		default:
+			w.walk(embeddedScope, embedded, depth+1)
+		}
+	}
+}
+
+// add adds a field competing for its JSON key
+func (w *fieldWalker) add(scope *fileScope, field *ast.Field, name string, tag jsonTag, depth int) {
+	w.candidates = append(w.candidates, newJSONCandidate(name, tag, depth))
+	w.fields = append(w.fields, structField{Field: field, name: name, scope: scope})
+}
+
+// newJSONCandidate is the candidate of a field named name with the json tag tag
+func newJSONCandidate(name string, tag jsonTag, depth int) jsonCandidate {
+	key := tag.Name
+	if key == "" {
+		key = name
+	}
+	return jsonCandidate{name: name, key: key, tagged: tag.Name != "", depth: depth}
+}
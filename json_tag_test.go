@@ -0,0 +1,151 @@
+package weave
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestParseJSONTagMatchesEncodingJSON(t *testing.T) {
+	// Names with backslashes or quotes are left out, as the encoding/json built on
+	// json/v2 (GOEXPERIMENT=jsonv2) reads them differently from the original one
+	tags := []string{
+		``,
+		`json:"name"`,
+		`json:"name,omitempty"`,
+		`json:",omitempty"`,
+		`json:"-"`,
+		`json:"-,"`,
+		`json:"-,omitempty"`,
+		`json:"a-b.c"`,
+		`json:"with space"`,
+		`json:"$ref"`,
+		`json:"ünïcode"`,
+		`json:"name,string"`,
+		`json:"name,omitempty,string"`,
+		`json:"" weave:"type=text"`,
+		`weave:"type=text"`,
+		`json:"name" xml:"other"`,
+	}
+
+	for _, tag := range tags {
+		// A struct with one int field tagged with tag, named Field by Go, which isn't
+		// empty so omitempty keeps it
+		typ := reflect.StructOf([]reflect.StructField{{Name: "Field", Type: reflect.TypeOf(0), Tag: reflect.StructTag(tag)}})
+		value := reflect.New(typ).Elem()
+		value.Field(0).SetInt(1)
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			t.Fatalf("%s: %v", tag, err)
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(encoded, &object); err != nil {
+			t.Fatalf("%s: %v", tag, err)
+		}
+
+		parsed := parseJSONTag(tag)
+		var got []string
+		switch {
+		case parsed.Skip:
+		case parsed.Name != "":
+			got = []string{parsed.Name}
+		default:
+			got = []string{"Field"}
+		}
+		if want := slices.Sorted(maps.Keys(object)); !slices.Equal(got, want) {
+			t.Errorf("parseJSONTag(%s) encodes the field as %q, encoding/json as %q", tag, got, want)
+		}
+		if _, isString := object[parsed.Name].(string); parsed.String != isString && !parsed.Skip {
+			t.Errorf("parseJSONTag(%s).String = %v, encoding/json quotes the value: %v", tag, parsed.String, isString)
+		}
+	}
+}
+
+// The structs jsonFields is compared with encoding/json on, read from this file's source
+
+type embeddedBase struct {
+	ID        string `json:"id"`
+	BaseTitle string `json:"baseTitle"`
+	Shared    string // tied with EmbeddedAudit.Shared
+}
+
+type EmbeddedAudit struct {
+	Editor string `json:"editor"`
+	Shared string
+	Author string `json:"Plain"` // wins over EmbeddedNote.Plain by its tag
+}
+
+type EmbeddedNote struct {
+	Plain string
+	Note  string `json:"note"`
+}
+
+type EmbeddedNames []string
+
+type embeddingArticle struct {
+	embeddedBase
+	*EmbeddedAudit
+	EmbeddedNote
+	EmbeddedNames
+	Title, Subtitle string
+	Skipped         EmbeddedNote `json:"-"`
+	Named           EmbeddedNote `json:"named"`
+	EmbeddedTagged  `json:"embeddedTagged"`
+	hidden          string
+}
+
+type EmbeddedTagged struct {
+	Inner string
+}
+
+func TestJSONFieldsMatchEncodingJSON(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "json_tag_test.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diags Diagnostics
+	scope := newFileScope(file, fset, nil, nil, &diags)
+	scope.structs = collectStructs([]*ast.File{file}, []*fileScope{scope})
+	scope.classes = map[string]bool{}
+
+	for _, value := range []interface{}{
+		embeddingArticle{EmbeddedAudit: &EmbeddedAudit{}},
+		EmbeddedAudit{},
+	} {
+		name := reflect.TypeOf(value).Name()
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(encoded, &object); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, f := range jsonFields(scope, name, scope.structs[name].spec.Type.(*ast.StructType)) {
+			key := f.name
+			if f.Tag != nil {
+				tag, _ := strconv.Unquote(f.Tag.Value)
+				if parsed := parseJSONTag(tag); parsed.Name != "" {
+					key = parsed.Name
+				}
+			}
+			got = append(got, key)
+		}
+		slices.Sort(got)
+		if want := slices.Sorted(maps.Keys(object)); !slices.Equal(got, want) {
+			t.Errorf("jsonFields(%s) = %q, encoding/json encodes %q", name, got, want)
+		}
+	}
+	if diags.HasErrors() {
+		t.Errorf("unexpected errors: %v", diags)
+	}
+}
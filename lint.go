@@ -0,0 +1,272 @@
+package weave
+
+import (
+	"fmt"
+	"go/token"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// LintConfig sets the severity of each lint rule
+type LintConfig struct {
+	// Rules maps a rule name to "error", "warning" or "off"; unlisted rules keep their default
+	Rules map[string]string `yaml:"rules"`
+}
+
+// LintRule is a check run against the generated schema
+type LintRule struct {
+	Name        string
+	Description string
+	Severity    Severity // default severity
+
+	// Query marks rules about how the generated query helpers behave against the schema,
+	// which `weave crud --lint-queries` also runs
+	Query bool
+
+	check func(schema *WeaviateSchemaDefinition, report reportFunc)
+}
+
+// reportFunc records a lint finding at the Go position of a class or property
+type reportFunc func(pos token.Position, format string, args ...interface{})
+
+// LintRules lists the available rules in the order they run
+var LintRules = []LintRule{
+	{
+		Name:        "class-description",
+		Description: "Every class needs a description",
+		Severity:    SeverityWarning,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				if class.Description == "" {
+					report(class.Pos, "class %s has no description; document the type or add a %s marker", class.Class, weaviateDescMarker)
+				}
+			}
+		},
+	},
+	{
+		Name:        "text-tokenization",
+		Description: "Text properties should declare their tokenization",
+		Severity:    SeverityWarning,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				for j := range class.Properties {
+					prop := &class.Properties[j]
+					if isTextType(prop.DataType) && prop.Tokenization == "" {
+						report(prop.Pos, "text property %s.%s doesn't declare a tokenization", class.Class, prop.Name)
+					}
+				}
+			}
+		},
+	},
+	{
+		Name:        "explicit-vectorizer",
+		Description: "Classes must configure their vectorizer instead of relying on the default",
+		Severity:    SeverityWarning,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				if class.defaultVectorizer {
+					report(class.Pos, "class %s uses the default vectorizer %s implicitly; set vectorizer in its %s marker", class.Class, class.Vectorizer, weaviateConfigMarker)
+				}
+			}
+		},
+	},
+	{
+		Name:        "reference-target",
+		Description: "References must target classes marked with " + weaviateMarker,
+		Severity:    SeverityError,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			classes := make(map[string]bool, len(schema.Classes))
+			for _, class := range schema.Classes {
+				classes[class.Class] = true
+			}
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				for j := range class.Properties {
+					prop := &class.Properties[j]
+					for _, dataType := range prop.DataType {
+						if isReferenceType(dataType) && !classes[dataType] {
+							report(prop.Pos, "property %s.%s references %s, which isn't a marked class", class.Class, prop.Name, dataType)
+						}
+					}
+				}
+			}
+		},
+	},
+	{
+		Name:        "keyword-search",
+		Description: "Classes need a searchable text property for BM25 keyword searches to match",
+		Severity:    SeverityWarning,
+		Query:       true,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				if !slices.ContainsFunc(class.Properties, func(prop WeaviateProperty) bool { return isTextType(prop.DataType) }) {
+					report(class.Pos, "class %s has no text properties, so its BM25 keyword search never matches", class.Class)
+					continue
+				}
+				searchable := 0
+				for j := range class.Properties {
+					prop := &class.Properties[j]
+					if !isTextType(prop.DataType) {
+						continue
+					}
+					if setting, ok := disabledIndex(prop, "indexSearchable"); ok {
+						report(prop.Pos, "text property %s.%s isn't searchable (%s), so BM25 skips it and finds nothing when WithProperties names it", class.Class, prop.Name, setting)
+					} else {
+						searchable++
+					}
+				}
+				if searchable == 0 {
+					report(class.Pos, "class %s has no searchable text properties, so its BM25 keyword search never matches", class.Class)
+				}
+			}
+		},
+	},
+	{
+		Name:        "filterable-property",
+		Description: "Properties named in GetByProperty and FilterIsNull must be filterable",
+		Severity:    SeverityWarning,
+		Query:       true,
+		check: func(schema *WeaviateSchemaDefinition, report reportFunc) {
+			for i := range schema.Classes {
+				class := &schema.Classes[i]
+				for j := range class.Properties {
+					prop := &class.Properties[j]
+					if reason, ok := unfilterableTypes[strings.Join(prop.DataType, ",")]; ok {
+						report(prop.Pos, "property %s.%s %s, so GetByProperty and FilterIsNull can't filter it", class.Class, prop.Name, reason)
+						continue
+					}
+					setting, ok := disabledIndex(prop, "indexFilterable")
+					if !ok {
+						continue
+					}
+					// Weaviate filters text with the searchable index when there's no filterable one
+					if _, unsearchable := disabledIndex(prop, "indexSearchable"); isTextType(prop.DataType) && !unsearchable {
+						continue
+					}
+					report(prop.Pos, "property %s.%s isn't filterable (%s), so GetByProperty and FilterIsNull can't filter it", class.Class, prop.Name, setting)
+				}
+			}
+		},
+	},
+}
+
+// unfilterableTypes explains why properties of these data types can't be filtered by value
+var unfilterableTypes = map[string]string{
+	"object":         "is a nested object, which Weaviate doesn't index",
+	"object[]":       "is a nested object, which Weaviate doesn't index",
+	"blob":           "is a blob, which Weaviate doesn't index",
+	"geoCoordinates": "holds geo coordinates, filtered only with FilterWithinGeoRange",
+	"phoneNumber":    "is a phone number, filtered only by its subfields",
+}
+
+// disabledIndex reports whether a property turns off the index named indexFilterable or
+// indexSearchable, and names the setting doing it. The config's index defaults are already
+// applied, and Weaviate builds the indexes left unset.
+func disabledIndex(prop *WeaviateProperty, index string) (string, bool) {
+	setting := prop.IndexFilterable
+	if index == "indexSearchable" {
+		setting = prop.IndexSearchable
+	}
+	switch {
+	case prop.IndexInverted != nil && !*prop.IndexInverted:
+		return "indexInverted=false", true
+	case setting != nil && !*setting:
+		return index + "=false", true
+	}
+	return "", false
+}
+
+// isTextType reports whether a data type holds text
+func isTextType(dataType []string) bool {
+	return len(dataType) == 1 && (dataType[0] == "text" || dataType[0] == "text[]")
+}
+
+// isReferenceType reports whether a data type names a class; primitive types are lowercase
+func isReferenceType(dataType string) bool {
+	r := []rune(dataType)
+	return len(r) > 0 && unicode.IsUpper(r[0])
+}
+
+// Lint checks the schema against the lint rules, with severities from cfg
+func Lint(schema *WeaviateSchemaDefinition, cfg LintConfig) (Diagnostics, error) {
+	severities, err := cfg.severities()
+	if err != nil {
+		return nil, err
+	}
+
+	// Findings are reported at a class or property, which tells the owning team
+	owners := make(map[token.Position]string)
+	for _, class := range schema.Classes {
+		if class.Owner == "" {
+			continue
+		}
+		owners[class.Pos] = class.Owner
+		for _, prop := range class.Properties {
+			owners[prop.Pos] = class.Owner
+		}
+	}
+
+	var diags Diagnostics
+	for _, rule := range LintRules {
+		severity, enabled := severities[rule.Name]
+		if !enabled {
+			continue
+		}
+		rule.check(schema, func(pos token.Position, format string, args ...interface{}) {
+			diags.add(severity, pos, format, args...)
+			diags[len(diags)-1].Rule = rule.Name
+			if pos.IsValid() {
+				diags[len(diags)-1].Owner = owners[pos]
+			}
+		})
+	}
+
+	return diags, nil
+}
+
+// LintQueries checks the schema against the query rules only, see LintRule.Query
+func LintQueries(schema *WeaviateSchemaDefinition, cfg LintConfig) (Diagnostics, error) {
+	diags, err := Lint(schema, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := diags[:0]
+	for _, diag := range diags {
+		if slices.ContainsFunc(LintRules, func(rule LintRule) bool { return rule.Query && rule.Name == diag.Rule }) {
+			queries = append(queries, diag)
+		}
+	}
+	return queries, nil
+}
+
+// severities resolves the severity of every enabled rule
+func (c LintConfig) severities() (map[string]Severity, error) {
+	severities := make(map[string]Severity, len(LintRules))
+	for _, rule := range LintRules {
+		severities[rule.Name] = rule.Severity
+	}
+
+	for name, level := range c.Rules {
+		if _, ok := severities[name]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		switch strings.ToLower(level) {
+		case "error":
+			severities[name] = SeverityError
+		case "warning":
+			severities[name] = SeverityWarning
+		case "off":
+			delete(severities, name)
+		default:
+			return nil, fmt.Errorf("invalid severity %q for lint rule %s (expected error, warning or off)", level, name)
+		}
+	}
+
+	return severities, nil
+}
@@ -0,0 +1,175 @@
+package weave
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// StructInfo describes a struct type declared in a package
+type StructInfo struct {
+	Name   string
+	Marked bool // the struct already has a +weave marker
+	Pos    token.Position
+}
+
+// ListStructs lists the struct types declared in the Go files of dir, skipping test
+// and generated files
+func ListStructs(dir string) ([]StructInfo, error) {
+	var structs []StructInfo
+	err := walkStructs(dir, func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec) {
+		structs = append(structs, StructInfo{
+			Name:   spec.Name.Name,
+			Marked: hasWeaviateMarker(typeDoc(decl, spec)),
+			Pos:    fset.Position(spec.Pos()),
+		})
+	})
+	return structs, err
+}
+
+// MarkStructs adds a +weave marker to the named structs of dir that don't have one,
+// followed by a +weave:config: marker with config when it isn't empty, e.g.
+// "vectorizer=text2vec-openai". It returns the paths of the changed files.
+func MarkStructs(dir string, names []string, config string) ([]string, error) {
+	lines := "// " + weaviateMarker + "\n"
+	if config != "" {
+		lines += "// " + weaviateConfigMarker + " " + config + "\n"
+	}
+
+	return rewriteStructs(dir, func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec) []textEdit {
+		if !slices.Contains(names, spec.Name.Name) || hasWeaviateMarker(typeDoc(decl, spec)) {
+			return nil
+		}
+		return []textEdit{insertAbove(fset, file, decl, spec, lines)}
+	})
+}
+
+// sourceFile is a parsed Go file with its source
+type sourceFile struct {
+	path string
+	src  []byte
+	ast  *ast.File
+}
+
+// walkStructs calls fn for every struct type declared in the Go files of dir, skipping
+// test and generated files
+func walkStructs(dir string, fn func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec)) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return fmt.Errorf("error reading directory %s: %v", dir, err)
+	}
+
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading file %s: %v", path, err)
+		}
+		goFile, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("error parsing file %s: %v", path, err)
+		}
+		if ast.IsGenerated(goFile) {
+			continue
+		}
+
+		file := &sourceFile{path: path, src: src, ast: goFile}
+		for _, decl := range goFile.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if _, ok := typeSpec.Type.(*ast.StructType); ok {
+					fn(fset, file, genDecl, typeSpec)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// textEdit replaces the source between two offsets with text
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// rewriteStructs applies the edits returned by edit for the struct declarations of dir.
+// The rest of the source is kept byte for byte: gofmt would reflow doc comments and
+// break the indented YAML of config blocks. It returns the paths of the changed files.
+func rewriteStructs(dir string, edit func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec) []textEdit) ([]string, error) {
+	edits := make(map[*sourceFile][]textEdit)
+	var files []*sourceFile
+
+	err := walkStructs(dir, func(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec) {
+		specEdits := edit(fset, file, decl, spec)
+		if len(specEdits) == 0 {
+			return
+		}
+		if _, ok := edits[file]; !ok {
+			files = append(files, file)
+		}
+		edits[file] = append(edits[file], specEdits...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, file := range files {
+		fileEdits := edits[file]
+		slices.SortStableFunc(fileEdits, func(a, b textEdit) int { return a.start - b.start })
+
+		var out []byte
+		last := 0
+		for _, edit := range fileEdits {
+			out = append(out, file.src[last:edit.start]...)
+			out = append(out, edit.text...)
+			last = edit.end
+		}
+		out = append(out, file.src[last:]...)
+
+		if err := os.WriteFile(file.path, out, 0644); err != nil {
+			return changed, fmt.Errorf("error writing %s: %v", file.path, err)
+		}
+		changed = append(changed, file.path)
+	}
+	return changed, nil
+}
+
+// insertAbove inserts comment lines above a struct declaration, where they join its doc
+// comment, indented like the declaration
+func insertAbove(fset *token.FileSet, file *sourceFile, decl *ast.GenDecl, spec *ast.TypeSpec, lines string) textEdit {
+	// A grouped type's doc comment sits above its spec, otherwise above the type keyword
+	pos := decl.Pos()
+	if decl.Lparen.IsValid() {
+		pos = spec.Pos()
+	}
+	offset := fset.Position(pos).Offset
+	lineStart := offset
+	for lineStart > 0 && file.src[lineStart-1] != '\n' {
+		lineStart--
+	}
+	indent := string(file.src[lineStart:offset])
+	text := indent + strings.ReplaceAll(strings.TrimSuffix(lines, "\n"), "\n", "\n"+indent) + "\n"
+	return textEdit{lineStart, lineStart, text}
+}
+
+// typeDoc returns the doc comment of a type, which belongs to the declaration unless
+// the type is declared in a group
+func typeDoc(decl *ast.GenDecl, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc != nil {
+		return spec.Doc
+	}
+	return decl.Doc
+}
@@ -0,0 +1,192 @@
+package weave
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaFragment is a schema generated on its own, e.g. by the team owning a model package
+type SchemaFragment struct {
+	Name string // file name, used in messages
+	JSON []byte // a {"classes": [...]} schema as written by weave, an array of classes or a class
+}
+
+// fragmentClass is a class of a fragment, kept as raw JSON so the merged schema holds
+// every setting as the fragment wrote it
+type fragmentClass struct {
+	name     string
+	fragment string
+	raw      json.RawMessage
+	settings map[string]interface{}
+}
+
+// MergeSchemaJSON combines schema fragments into one schema. A class defined by several
+// fragments is an error unless the definitions are identical, as are references to
+// classes no fragment defines. Module settings every class of a fragment shares, like
+// the defaults of its config file, are reported when fragments disagree on them.
+func MergeSchemaJSON(fragments []SchemaFragment) ([]byte, Diagnostics, error) {
+	var diags Diagnostics
+	var classes []fragmentClass
+	byName := make(map[string]int)
+
+	for _, fragment := range fragments {
+		fragmentClasses, err := decodeFragment(fragment)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, class := range fragmentClasses {
+			key := strings.ToLower(class.name)
+			i, ok := byName[key]
+			switch {
+			case !ok:
+				byName[key] = len(classes)
+				classes = append(classes, class)
+			case classes[i].name != class.name:
+				diags.add(SeverityError, noPos, "class %s of %s collides with class %s of %s", class.name, class.fragment, classes[i].name, classes[i].fragment)
+			case !reflect.DeepEqual(classes[i].settings, class.settings):
+				diags.add(SeverityError, noPos, "class %s is defined by both %s and %s, differently", class.name, classes[i].fragment, class.fragment)
+			}
+		}
+	}
+
+	for _, class := range classes {
+		for _, ref := range classReferences(class.settings) {
+			if _, ok := byName[strings.ToLower(ref.To)]; !ok {
+				diags.add(SeverityError, noPos, "property %s.%s of %s references %s, which no fragment defines", class.name, ref.Property, class.fragment, ref.To)
+			}
+		}
+	}
+
+	diags = append(diags, conflictingModuleDefaults(fragments, classes)...)
+
+	var b bytes.Buffer
+	b.WriteString("{\n  \"classes\": [")
+	for i, class := range classes {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n    ")
+		if err := json.Indent(&b, class.raw, "    ", "  "); err != nil {
+			return nil, diags, fmt.Errorf("error formatting class %s: %v", class.name, err)
+		}
+	}
+	if len(classes) > 0 {
+		b.WriteString("\n  ")
+	}
+	b.WriteString("]\n}\n")
+
+	return b.Bytes(), diags, nil
+}
+
+// noPos is the position of problems found in schema JSON rather than Go sources
+var noPos token.Position
+
+// decodeFragment reads the classes of a fragment
+func decodeFragment(fragment SchemaFragment) ([]fragmentClass, error) {
+	var raws []json.RawMessage
+	trimmed := bytes.TrimSpace(fragment.JSON)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", fragment.Name, err)
+		}
+	default:
+		var schema struct {
+			Classes *[]json.RawMessage `json:"classes"`
+		}
+		if err := json.Unmarshal(trimmed, &schema); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", fragment.Name, err)
+		}
+		if schema.Classes != nil {
+			raws = *schema.Classes
+		} else {
+			raws = []json.RawMessage{trimmed}
+		}
+	}
+
+	classes := make([]fragmentClass, 0, len(raws))
+	for i, raw := range raws {
+		var settings map[string]interface{}
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return nil, fmt.Errorf("error reading class %d of %s: %v", i, fragment.Name, err)
+		}
+		name, _ := settings["class"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("class %d of %s has no name", i, fragment.Name)
+		}
+		classes = append(classes, fragmentClass{name: name, fragment: fragment.Name, raw: raw, settings: settings})
+	}
+	return classes, nil
+}
+
+// classReferences lists the references of a class decoded from JSON
+func classReferences(class map[string]interface{}) []reference {
+	var refs []reference
+	props, _ := class["properties"].([]interface{})
+	for _, p := range props {
+		prop, _ := p.(map[string]interface{})
+		name, _ := prop["name"].(string)
+		dataTypes, _ := prop["dataType"].([]interface{})
+		for _, d := range dataTypes {
+			if dataType, _ := d.(string); isReferenceType(dataType) {
+				refs = append(refs, reference{Property: name, To: dataType})
+			}
+		}
+	}
+	return refs
+}
+
+// conflictingModuleDefaults warns about the module settings shared by every class of a
+// fragment that another fragment sets to a different shared value
+func conflictingModuleDefaults(fragments []SchemaFragment, classes []fragmentClass) Diagnostics {
+	type setting struct {
+		fragment string
+		value    interface{}
+	}
+	shared := make(map[string][]setting) // module.setting -> the shared value of each fragment
+
+	for _, fragment := range fragments {
+		values := make(map[string]interface{})
+		conflicting := make(map[string]bool)
+		for _, class := range classes {
+			if class.fragment != fragment.Name {
+				continue
+			}
+			moduleConfig, _ := class.settings["moduleConfig"].(map[string]interface{})
+			for module, config := range moduleConfig {
+				settings, _ := config.(map[string]interface{})
+				for name, value := range settings {
+					key := module + "." + name
+					if prev, ok := values[key]; ok && !reflect.DeepEqual(prev, value) {
+						conflicting[key] = true
+					}
+					values[key] = value
+				}
+			}
+		}
+		for key, value := range values {
+			if !conflicting[key] {
+				shared[key] = append(shared[key], setting{fragment.Name, value})
+			}
+		}
+	}
+
+	var diags Diagnostics
+	for _, key := range slices.Sorted(maps.Keys(shared)) {
+		settings := shared[key]
+		for _, s := range settings[1:] {
+			if !reflect.DeepEqual(s.value, settings[0].value) {
+				diags.add(SeverityWarning, noPos, "%s sets moduleConfig %s to %s but %s to %s; check the defaults of their config files",
+					settings[0].fragment, key, formatDiffValue(settings[0].value), s.fragment, formatDiffValue(s.value))
+				break
+			}
+		}
+	}
+	return diags
+}
@@ -0,0 +1,49 @@
+package weave
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Metadata traces a generated schema back to the sources and tool it was generated by.
+// It's written as the x-weave block of the schema JSON and as constants in generated code.
+type Metadata struct {
+	Version     string    `json:"version"`          // weave version
+	Commit      string    `json:"commit,omitempty"` // git commit of the sources, when in a repository
+	SourceHash  string    `json:"sourceHash"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// newMetadata collects the metadata of a schema generated from the source directories
+func newMetadata(dirs []string, sourceHash string) *Metadata {
+	metadata := &Metadata{
+		Version:     Version(),
+		SourceHash:  sourceHash,
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if len(dirs) > 0 {
+		metadata.Commit = gitCommit(dirs[0])
+	}
+	return metadata
+}
+
+// gitCommit returns the commit checked out in the repository holding dir, or "" outside one
+func gitCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// generateMetadataCode writes the schema metadata as constants
+func generateMetadataCode(packageName, notice string, metadata *Metadata, outputDir string) error {
+	templateData := TemplateData[*Metadata]{
+		AutogeneratedNotice: notice,
+		PackageName:         packageName,
+		Data:                metadata,
+	}
+	return generateFromTemplate("metadata", templateData, filepath.Join(outputDir, "weave_metadata.go"))
+}
@@ -0,0 +1,415 @@
+package weave
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MigrationStepKind is what a migration step does
+type MigrationStepKind string
+
+const (
+	StepCreateClass  MigrationStepKind = "create-class"
+	StepAddProperty  MigrationStepKind = "add-property"
+	StepCopyObjects  MigrationStepKind = "copy-objects"
+	StepMoveProperty MigrationStepKind = "move-property"
+	StepDeleteClass  MigrationStepKind = "delete-class"
+)
+
+// MigrationStep is one step of a migration plan. From is the class objects are copied
+// from, or the property values are moved from.
+type MigrationStep struct {
+	Kind     MigrationStepKind `json:"kind"`
+	Class    string            `json:"class"`
+	Property string            `json:"property,omitempty"`
+	From     string            `json:"from,omitempty"`
+}
+
+// String describes the step as a line of the plan
+func (s MigrationStep) String() string {
+	switch s.Kind {
+	case StepCreateClass:
+		return "create class " + s.Class
+	case StepAddProperty:
+		return fmt.Sprintf("add property %s.%s", s.Class, s.Property)
+	case StepCopyObjects:
+		return fmt.Sprintf("copy the objects of %s to %s, rewriting references to renamed classes", s.From, s.Class)
+	case StepMoveProperty:
+		return fmt.Sprintf("move the values of %s.%s to %s.%s", s.Class, s.From, s.Class, s.Property)
+	case StepDeleteClass:
+		return "delete class " + s.Class
+	}
+	return string(s.Kind) + " " + s.Class
+}
+
+// MigrationPlan lists the steps moving the data on a cluster to the schema, in order
+type MigrationPlan struct {
+	Steps []MigrationStep `json:"steps"`
+
+	// Renames maps the former names of the classes being renamed to their names
+	Renames map[string]string `json:"renames,omitempty"`
+}
+
+// MigrationOptions controls how ApplyMigration copies objects
+type MigrationOptions struct {
+	PageSize  int // objects read per cursor request
+	BatchSize int // objects written per batch request
+}
+
+// MigrationResult records what ApplyMigration did, even when it fails
+type MigrationResult struct {
+	Done   []MigrationStep
+	Copied map[string]int // objects copied or rewritten per class
+}
+
+// PlanMigration plans the data migrations the schema asks for, against the classes on
+// the cluster.
+//
+// A class with a renamedFrom marker whose old class exists is created, the objects of the
+// old class are copied to it with their IDs and vectors, references to renamed classes
+// are rewritten, and the old class is deleted. Classes the cluster keeps mustn't reference
+// a renamed class, as Weaviate can't change their data types.
+//
+// A property with the renamedFrom tag option whose old property exists is added, and the
+// values of the old property are moved to it, object by object. Weaviate can't drop
+// properties, so the old one stays in the schema, without values. Properties of renamed
+// classes are renamed while their objects are copied.
+func PlanMigration(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition) (*MigrationPlan, error) {
+	var current WeaviateSchemaDefinition
+	if err := client.do(ctx, http.MethodGet, "/schema", nil, nil, &current); err != nil {
+		return nil, fmt.Errorf("error reading schema: %v", err)
+	}
+
+	plan := &MigrationPlan{Renames: make(map[string]string)}
+	var adds, copies, moves, deletes []MigrationStep
+	for _, class := range schema.Classes {
+		if class.RenamedFrom != "" {
+			if _, ok := current.class(class.RenamedFrom); ok {
+				plan.Renames[class.RenamedFrom] = class.Class
+				if _, ok := current.class(class.Class); !ok {
+					plan.Steps = append(plan.Steps, MigrationStep{Kind: StepCreateClass, Class: class.Class})
+				}
+				copies = append(copies, MigrationStep{Kind: StepCopyObjects, Class: class.Class, From: class.RenamedFrom})
+				deletes = append(deletes, MigrationStep{Kind: StepDeleteClass, Class: class.RenamedFrom})
+				continue
+			}
+		}
+
+		existing, ok := current.class(class.Class)
+		if !ok {
+			continue
+		}
+		for _, prop := range class.Properties {
+			if prop.RenamedFrom == "" || !hasProperty(existing, prop.RenamedFrom) {
+				continue
+			}
+			if !hasProperty(existing, prop.Name) {
+				adds = append(adds, MigrationStep{Kind: StepAddProperty, Class: class.Class, Property: prop.Name})
+			}
+			moves = append(moves, MigrationStep{Kind: StepMoveProperty, Class: class.Class, Property: prop.Name, From: prop.RenamedFrom})
+		}
+	}
+
+	for _, class := range current.Classes {
+		if _, renamed := plan.Renames[class.Class]; renamed {
+			continue
+		}
+		for _, prop := range class.Properties {
+			for _, dataType := range prop.DataType {
+				if to, ok := plan.Renames[dataType]; ok {
+					return nil, fmt.Errorf("property %s.%s references %s, which is renamed to %s; Weaviate can't change the data type of a property, so rename %s along with it", class.Class, prop.Name, dataType, to, class.Class)
+				}
+			}
+		}
+	}
+
+	plan.Steps = slices.Concat(plan.Steps, adds, copies, moves, deletes)
+	return plan, nil
+}
+
+// hasProperty reports whether a class has a property, matching names like Weaviate does
+func hasProperty(class WeaviateClass, name string) bool {
+	return slices.ContainsFunc(class.Properties, func(prop WeaviateProperty) bool {
+		return strings.EqualFold(prop.Name, name)
+	})
+}
+
+// ApplyMigration runs the steps of a plan made by PlanMigration. The classes and
+// properties to create are taken from schema; classes are created like Apply does.
+// Copies and moves rewrite whole objects, keeping their IDs and vectors, so they should
+// run while nothing else writes the classes. When a migration fails, planning and
+// applying it again resumes it.
+func ApplyMigration(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition, plan *MigrationPlan, opts MigrationOptions) (MigrationResult, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultDumpPageSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultRestoreBatch
+	}
+	result := MigrationResult{Copied: make(map[string]int)}
+
+	// The new classes are created together, so the ones referencing each other are ordered
+	create := &WeaviateSchemaDefinition{}
+	for _, step := range plan.Steps {
+		if step.Kind != StepCreateClass {
+			continue
+		}
+		class, ok := schema.class(step.Class)
+		if !ok {
+			return result, fmt.Errorf("class %s of the plan isn't in the schema", step.Class)
+		}
+		create.Classes = append(create.Classes, class)
+	}
+	if len(create.Classes) > 0 {
+		if _, err := Apply(ctx, client, create, ApplyOptions{}); err != nil {
+			return result, err
+		}
+	}
+
+	for _, step := range plan.Steps {
+		class, _ := schema.class(step.Class)
+		switch step.Kind {
+		case StepAddProperty:
+			i := slices.IndexFunc(class.Properties, func(prop WeaviateProperty) bool { return prop.Name == step.Property })
+			if i < 0 {
+				return result, fmt.Errorf("property %s.%s of the plan isn't in the schema", step.Class, step.Property)
+			}
+			if err := client.addProperty(ctx, step.Class, class.Properties[i]); err != nil {
+				return result, err
+			}
+		case StepCopyObjects:
+			n, err := copyObjects(ctx, client, step.From, step.Class, plan.Renames, propertyRenames(class), opts)
+			result.Copied[step.Class] += n
+			if err != nil {
+				return result, err
+			}
+		case StepMoveProperty:
+			n, err := moveProperty(ctx, client, step.Class, step.From, step.Property, opts)
+			result.Copied[step.Class] += n
+			if err != nil {
+				return result, err
+			}
+		case StepDeleteClass:
+			if err := client.deleteClass(ctx, step.Class); err != nil {
+				return result, err
+			}
+		}
+		result.Done = append(result.Done, step)
+	}
+	return result, nil
+}
+
+// propertyRenames maps the former names of the renamed properties of a class to their names
+func propertyRenames(class WeaviateClass) map[string]string {
+	renames := make(map[string]string)
+	for _, prop := range class.Properties {
+		if prop.RenamedFrom != "" {
+			renames[prop.RenamedFrom] = prop.Name
+		}
+	}
+	return renames
+}
+
+// copyObjects copies the objects of a class to another one, creating the tenants the
+// target lacks, and renames references to renamed classes and renamed properties
+func copyObjects(ctx context.Context, client *ClusterClient, from, to string, classRenames, propRenames map[string]string, opts MigrationOptions) (int, error) {
+	tenants, err := copyTenants(ctx, client, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = forEachObjectPage(ctx, client, from, tenants, opts.PageSize, func(objects []DumpObject) error {
+		for i := range objects {
+			obj := &objects[i]
+			obj.Class = to
+			props, err := decodeObjectProperties(obj.Properties)
+			if err != nil {
+				return fmt.Errorf("error reading %s object %s: %v", from, obj.ID, err)
+			}
+			renameReferences(props, classRenames)
+			for old, name := range propRenames {
+				if value, ok := props[old]; ok {
+					if _, ok := props[name]; !ok {
+						props[name] = value
+					}
+					delete(props, old)
+				}
+			}
+			if obj.Properties, err = json.Marshal(props); err != nil {
+				return fmt.Errorf("error writing %s object %s: %v", from, obj.ID, err)
+			}
+		}
+		for batch := range slices.Chunk(objects, opts.BatchSize) {
+			if err := client.batchObjects(ctx, batch); err != nil {
+				return fmt.Errorf("error copying %s objects to %s: %v", from, to, err)
+			}
+			count += len(batch)
+		}
+		return nil
+	})
+	return count, err
+}
+
+// moveProperty moves the values of a property to another one of the same class, in the
+// objects holding a value of the old property. An object with a value of the new property
+// keeps it. Objects are rewritten whole, since Weaviate can't remove a single value.
+func moveProperty(ctx context.Context, client *ClusterClient, className, from, to string, opts MigrationOptions) (int, error) {
+	tenants, err := copyTenants(ctx, client, className, className)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = forEachObjectPage(ctx, client, className, tenants, opts.PageSize, func(objects []DumpObject) error {
+		var moved []DumpObject
+		for _, obj := range objects {
+			props, err := decodeObjectProperties(obj.Properties)
+			if err != nil {
+				return fmt.Errorf("error reading %s object %s: %v", className, obj.ID, err)
+			}
+			value, ok := props[from]
+			if !ok {
+				continue
+			}
+			if _, ok := props[to]; !ok {
+				props[to] = value
+			}
+			delete(props, from)
+			if obj.Properties, err = json.Marshal(props); err != nil {
+				return fmt.Errorf("error writing %s object %s: %v", className, obj.ID, err)
+			}
+			moved = append(moved, obj)
+		}
+		for batch := range slices.Chunk(moved, opts.BatchSize) {
+			if err := client.batchObjects(ctx, batch); err != nil {
+				return fmt.Errorf("error moving %s.%s to %s: %v", className, from, to, err)
+			}
+			count += len(batch)
+		}
+		return nil
+	})
+	return count, err
+}
+
+// copyTenants lists the tenants of a class, or "" when multi-tenancy is off, creating the
+// ones another class lacks
+func copyTenants(ctx context.Context, client *ClusterClient, from, to string) ([]string, error) {
+	fromTenants, err := client.classTenants(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if fromTenants == nil {
+		return []string{""}, nil
+	}
+
+	toTenants := fromTenants
+	if to != from {
+		if toTenants, err = client.classTenants(ctx, to); err != nil {
+			return nil, err
+		}
+	}
+	var tenants, missing []string
+	for name := range fromTenants {
+		tenants = append(tenants, name)
+		if !toTenants[name] {
+			missing = append(missing, name)
+		}
+	}
+	slices.Sort(tenants)
+	slices.Sort(missing)
+	if len(missing) > 0 {
+		if err := client.addTenants(ctx, to, missing); err != nil {
+			return nil, err
+		}
+	}
+	return tenants, nil
+}
+
+// forEachObjectPage pages through the objects of a class, with vectors, in every tenant
+// with the objects cursor, like Dump does
+func forEachObjectPage(ctx context.Context, client *ClusterClient, className string, tenants []string, pageSize int, fn func([]DumpObject) error) error {
+	for _, tenant := range tenants {
+		if err := objectPages(ctx, client, className, tenant, "", pageSize, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectPages pages through the objects of a class in a tenant, or "" without
+// multi-tenancy, with vectors, starting after the object with ID after unless it's ""
+func objectPages(ctx context.Context, client *ClusterClient, className, tenant, after string, pageSize int, fn func([]DumpObject) error) error {
+	for {
+		query := url.Values{
+			"class":   {className},
+			"limit":   {strconv.Itoa(pageSize)},
+			"include": {"vector"},
+		}
+		if after != "" {
+			query.Set("after", after)
+		}
+		if tenant != "" {
+			query.Set("tenant", tenant)
+		}
+
+		var page struct {
+			Objects []DumpObject `json:"objects"`
+		}
+		if err := client.do(ctx, http.MethodGet, "/objects", query, nil, &page); err != nil {
+			return fmt.Errorf("error reading %s objects: %v", className, err)
+		}
+		if len(page.Objects) == 0 {
+			return nil
+		}
+		after = page.Objects[len(page.Objects)-1].ID
+
+		if err := fn(page.Objects); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeObjectProperties decodes the properties of an object read from the cluster
+func decodeObjectProperties(properties json.RawMessage) (map[string]interface{}, error) {
+	props := make(map[string]interface{})
+	if len(properties) == 0 {
+		return props, nil
+	}
+	if err := json.Unmarshal(properties, &props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// renameReferences rewrites the beacons of references to renamed classes,
+// weaviate://localhost/<class>/<id>, to the classes' new names
+func renameReferences(props map[string]interface{}, renames map[string]string) {
+	for _, value := range props {
+		refs, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range refs {
+			ref, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			beacon, ok := ref["beacon"].(string)
+			if !ok {
+				continue
+			}
+			class, id, ok := strings.Cut(strings.TrimPrefix(beacon, "weaviate://localhost/"), "/")
+			if to, renamed := renames[class]; ok && renamed {
+				ref["beacon"] = "weaviate://localhost/" + to + "/" + id
+				delete(ref, "href")
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package weave
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ModuleUse is a Weaviate module the schema depends on
+type ModuleUse struct {
+	Module  string   `json:"module"`
+	Kind    string   `json:"kind"`    // vectorizer, generative, reranker, qna, backup or other
+	Classes []string `json:"classes"` // classes using it, empty for backup modules
+}
+
+// moduleKinds classify modules by the prefix of their name
+var moduleKinds = []struct{ prefix, kind string }{
+	{"text2vec-", "vectorizer"},
+	{"multi2vec-", "vectorizer"},
+	{"img2vec-", "vectorizer"},
+	{"ref2vec-", "vectorizer"},
+	{"generative-", "generative"},
+	{"reranker-", "reranker"},
+	{"qna-", "qna"},
+	{"backup-", "backup"},
+}
+
+// moduleKind classifies a module by its name
+func moduleKind(module string) string {
+	for _, k := range moduleKinds {
+		if strings.HasPrefix(module, k.prefix) {
+			return k.kind
+		}
+	}
+	return "other"
+}
+
+// Modules lists the modules the classes of the schema use, sorted by name: their
+// vectorizers and the modules their class and property moduleConfig configure
+func (s *WeaviateSchemaDefinition) Modules() []ModuleUse {
+	classes := make(map[string][]string)
+	for _, class := range s.Classes {
+		for _, module := range classModules(class) {
+			classes[module] = append(classes[module], class.Class)
+		}
+	}
+
+	modules := make([]ModuleUse, 0, len(classes))
+	for _, module := range slices.Sorted(maps.Keys(classes)) {
+		modules = append(modules, ModuleUse{Module: module, Kind: moduleKind(module), Classes: classes[module]})
+	}
+	return modules
+}
+
+// classModules lists the modules a class uses: its vectorizers and the modules its
+// moduleConfig and its properties' configure, such as generative and reranker modules
+func classModules(class WeaviateClass) []string {
+	var modules []string
+	add := func(module string) {
+		if module != "" && module != "none" && !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+
+	add(class.Vectorizer)
+	for _, name := range slices.Sorted(maps.Keys(class.VectorConfig)) {
+		vector, _ := class.VectorConfig[name].(map[string]interface{})
+		vectorizer, _ := vector["vectorizer"].(map[string]interface{})
+		for _, module := range slices.Sorted(maps.Keys(vectorizer)) {
+			add(module)
+		}
+	}
+	for _, module := range slices.Sorted(maps.Keys(class.ModuleConfig)) {
+		add(module)
+	}
+
+	var addProperties func(props []WeaviateProperty)
+	addProperties = func(props []WeaviateProperty) {
+		for _, prop := range props {
+			for _, module := range slices.Sorted(maps.Keys(prop.ModuleConfig)) {
+				add(module)
+			}
+			addProperties(prop.NestedProperties)
+		}
+	}
+	addProperties(class.Properties)
+	return modules
+}
@@ -0,0 +1,84 @@
+package weave
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives property names from Go field names without a json tag
+type NamingStrategy string
+
+const (
+	NamingCamelCase NamingStrategy = "camelCase"  // UserID -> userID, HTTPServer -> httpServer
+	NamingSnakeCase NamingStrategy = "snake_case" // UserID -> user_id, HTTPServer -> http_server
+	NamingKeep      NamingStrategy = "keep"       // the field name as is
+)
+
+// propertyNamePattern is the pattern Weaviate requires property names to match
+var propertyNamePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]{0,230}$`)
+
+// validate checks the strategy is known; empty selects camelCase
+func (n NamingStrategy) validate() error {
+	switch n {
+	case "", NamingCamelCase, NamingSnakeCase, NamingKeep:
+		return nil
+	}
+	return fmt.Errorf("unknown naming strategy %q (expected %s, %s or %s)", n, NamingCamelCase, NamingSnakeCase, NamingKeep)
+}
+
+// propertyName converts a Go field name to a property name
+func (n NamingStrategy) propertyName(fieldName string) string {
+	switch n {
+	case NamingKeep:
+		return fieldName
+	case NamingSnakeCase:
+		words := splitWords(fieldName)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		return strings.Join(words, "_")
+	}
+
+	words := splitWords(fieldName)
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+// splitWords splits a Go identifier into words at case changes and underscores, keeping
+// initialisms together: HTTPServerID -> HTTP, Server, ID. Digits stay with the word before.
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] != '_' && !wordBoundary(runes, i) {
+			continue
+		}
+		if i > start {
+			words = append(words, string(runes[start:i]))
+		}
+		start = i
+		if i < len(runes) && runes[i] == '_' {
+			start++
+		}
+	}
+	if len(words) == 0 {
+		return []string{name}
+	}
+	return words
+}
+
+// wordBoundary reports whether a new word starts at runes[i]
+func wordBoundary(runes []rune, i int) bool {
+	prev, cur := runes[i-1], runes[i]
+	if !unicode.IsUpper(cur) {
+		return false
+	}
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	// The last capital of an initialism starts the next word: HTTPServer -> HTTP, Server
+	return unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
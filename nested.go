@@ -0,0 +1,77 @@
+package weave
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// collectClasses finds the names of the types marked as classes across the package
+func collectClasses(files []*ast.File) map[string]bool {
+	classes := make(map[string]bool)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if ok && (hasWeaviateMarker(genDecl.Doc) || hasWeaviateMarker(typeSpec.Doc)) {
+					classes[typeSpec.Name.Name] = true
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// objectStruct returns the unmarked struct a field of an object data type holds, through
+// pointers and slices, with the scope its fields resolve in
+func objectStruct(scope *fileScope, expr ast.Expr) (*ast.StructType, *fileScope, string) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return objectStruct(scope, t.X)
+	case *ast.ArrayType:
+		return objectStruct(scope, t.Elt)
+	case *ast.StructType:
+		return t, scope, "struct"
+	case *ast.Ident:
+		if decl, ok := scope.structs[t.Name]; ok && decl.spec.TypeParams == nil && !scope.classes[t.Name] {
+			return decl.spec.Type.(*ast.StructType), decl.scope, t.Name
+		}
+	}
+	return nil, nil, ""
+}
+
+// nestedProperties converts the struct held by an object field into nested properties.
+// Nested objects can't contain themselves, so a struct nesting itself is an error.
+func nestedProperties(scope *fileScope, field *ast.Field, structName, fieldName string) ([]WeaviateProperty, bool) {
+	structType, structScope, name := objectStruct(scope, field.Type)
+	if structType == nil {
+		return nil, true
+	}
+	if scope.nesting[structType] {
+		scope.errorf(field.Pos(), "field %s.%s nests %s inside itself, which an object property can't hold", structName, fieldName, name)
+		return nil, false
+	}
+
+	scope.nesting[structType] = true
+	defer delete(scope.nesting, structType)
+
+	reported := len(*scope.diags)
+	nested := processStruct(structScope, "", name, structType)
+	if len(nested.Properties) == 0 {
+		if len(*scope.diags) > reported {
+			return nil, false // the fields' own errors explain why
+		}
+		scope.errorf(field.Pos(), "field %s.%s holds %s, which has no properties; Weaviate requires nested properties for objects", structName, fieldName, name)
+		return nil, false
+	}
+	for _, prop := range nested.Properties {
+		if len(prop.DataType) == 1 && isReferenceType(prop.DataType[0]) {
+			scope.diags.add(SeverityError, prop.Pos, "field %s.%s references class %s, but nested objects can't hold references", name, prop.GoField, prop.DataType[0])
+			return nil, false
+		}
+	}
+	return nested.Properties, true
+}
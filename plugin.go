@@ -0,0 +1,159 @@
+package weave
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"strings"
+)
+
+// GeneratorPluginPrefix starts the name of the executables weave runs as generator
+// plugins, e.g. weave-gen-python for --plugin python
+const GeneratorPluginPrefix = "weave-gen-"
+
+// GeneratorRequest is what a generator plugin receives: the schema it generates files for
+type GeneratorRequest struct {
+	// Schema is the schema as weave schema writes it
+	Schema json.RawMessage `json:"schema"`
+
+	// Parameter is the text after the plugin name in --plugin name:parameter, empty if none
+	Parameter string `json:"parameter,omitempty"`
+
+	// WeaveVersion is the version of weave running the plugin, see Version
+	WeaveVersion string `json:"weaveVersion"`
+}
+
+// GeneratedFile is a file written by a generator plugin. Name is a slash-separated path
+// relative to the output directory.
+type GeneratedFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// GeneratorResponse is what a generator plugin returns: the files it generated, or the
+// error it stopped at
+type GeneratorResponse struct {
+	Files []GeneratedFile `json:"files"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Generator is an additional target weave generates files for, e.g. a client in another
+// language. Go plugins export it as a variable named Generator.
+type Generator interface {
+	Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error)
+}
+
+// ExecGenerator runs an executable as a generator, writing the GeneratorRequest as JSON to
+// its stdin and reading the GeneratorResponse as JSON from its stdout, the way protoc runs
+// its plugins. Its stderr is passed through.
+type ExecGenerator struct {
+	Path string
+}
+
+// Generate implements Generator
+func (g ExecGenerator) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling generator request: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, g.Path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error running %s: %v", g.Path, err)
+	}
+
+	var resp GeneratorResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("error reading the response of %s: %v", g.Path, err)
+	}
+	return &resp, nil
+}
+
+// LoadGenerator finds the generator plugin named name: a Go plugin when name is a path
+// ending in .so, an executable when it's any other path, and otherwise the executable
+// GeneratorPluginPrefix+name on the PATH. Go plugins must be built with the same Go
+// release and weave version as the weave binary loading them.
+func LoadGenerator(name string) (Generator, error) {
+	if strings.HasSuffix(name, ".so") {
+		return loadGoPlugin(name)
+	}
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return ExecGenerator{Path: name}, nil
+	}
+
+	path, err := exec.LookPath(GeneratorPluginPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("generator plugin %s not found: no %s%s on the PATH", name, GeneratorPluginPrefix, name)
+	}
+	return ExecGenerator{Path: path}, nil
+}
+
+// loadGoPlugin opens a Go plugin and returns its exported Generator variable
+func loadGoPlugin(path string) (Generator, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening generator plugin %s: %v", path, err)
+	}
+	sym, err := p.Lookup("Generator")
+	if err != nil {
+		return nil, fmt.Errorf("generator plugin %s doesn't export Generator: %v", path, err)
+	}
+
+	switch g := sym.(type) {
+	case *Generator:
+		return *g, nil
+	case Generator:
+		return g, nil
+	}
+	return nil, fmt.Errorf("generator plugin %s exports Generator as %T, which isn't a weave.Generator", path, sym)
+}
+
+// RunGenerator runs a generator for the schema and writes the files it returns below
+// outputDir, returning their paths. Files naming a path outside outputDir are an error.
+func RunGenerator(ctx context.Context, gen Generator, schema *WeaviateSchemaDefinition, parameter, outputDir string) ([]string, error) {
+	schemaJSON, err := schema.ToJSON(false)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling schema: %v", err)
+	}
+
+	resp, err := gen.Generate(ctx, &GeneratorRequest{
+		Schema:       schemaJSON,
+		Parameter:    parameter,
+		WeaveVersion: Version(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("generator failed: %s", resp.Error)
+	}
+
+	// Every name is checked before anything is written, so a bad response leaves no files behind
+	paths := make([]string, len(resp.Files))
+	for i, file := range resp.Files {
+		name := filepath.FromSlash(file.Name)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("generator returned file %q outside the output directory", file.Name)
+		}
+		paths[i] = filepath.Join(outputDir, name)
+	}
+
+	for i, file := range resp.Files {
+		if err := os.MkdirAll(filepath.Dir(paths[i]), 0755); err != nil {
+			return nil, fmt.Errorf("error creating directory for %s: %v", paths[i], err)
+		}
+		if err := os.WriteFile(paths[i], []byte(file.Content), 0644); err != nil {
+			return nil, fmt.Errorf("error writing %s: %v", paths[i], err)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,81 @@
+package weave
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Preflight checks a cluster can take schema before it's applied, reporting problems
+// Weaviate would otherwise refuse with less helpful errors halfway through an apply:
+// modules the classes use that aren't enabled, features the cluster's release lacks,
+// nodes that aren't healthy and replication factors above the number of nodes. The
+// error is for failing to read the cluster's state.
+func Preflight(ctx context.Context, client *ClusterClient, schema *WeaviateSchemaDefinition) (Diagnostics, error) {
+	var meta struct {
+		Version string                 `json:"version"`
+		Modules map[string]interface{} `json:"modules"`
+	}
+	if err := client.do(ctx, http.MethodGet, "/meta", nil, nil, &meta); err != nil {
+		return nil, fmt.Errorf("error reading cluster metadata: %v", err)
+	}
+	var nodes struct {
+		Nodes []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"nodes"`
+	}
+	if err := client.do(ctx, http.MethodGet, "/nodes", nil, nil, &nodes); err != nil {
+		return nil, fmt.Errorf("error reading cluster nodes: %v", err)
+	}
+
+	var diags Diagnostics
+	enabled := slices.Sorted(maps.Keys(meta.Modules))
+	for _, class := range schema.Classes {
+		for _, module := range classModules(class) {
+			if _, ok := meta.Modules[module]; ok {
+				continue
+			}
+			hint := "enable it on the cluster"
+			if module == class.Vectorizer && class.defaultVectorizer {
+				hint = "it's the default vectorizer; set one with +weave:config: vectorizer=... or enable it on the cluster"
+			}
+			diags.add(SeverityError, class.Pos, "class %s uses module %s, which isn't enabled on the cluster (enabled: %s); %s", class.Class, module, moduleList(enabled), hint)
+		}
+
+		// The factor is a float64 from inline config and an int from YAML blocks
+		var factor int
+		switch f := class.ReplicationConfig["factor"].(type) {
+		case float64:
+			factor = int(f)
+		case int:
+			factor = f
+		}
+		if factor > len(nodes.Nodes) {
+			diags.add(SeverityError, class.Pos, "class %s has replication factor %d, but the cluster has %d nodes to hold the replicas", class.Class, factor, len(nodes.Nodes))
+		}
+	}
+
+	if version, err := ParseWeaviateVersion(meta.Version); err == nil {
+		checkVersionFeatures(schema, version, "the cluster runs "+meta.Version, &diags)
+	}
+
+	for _, node := range nodes.Nodes {
+		if node.Status != "HEALTHY" {
+			diags.add(SeverityError, noPos, "node %s is %s; wait until it's healthy or remove it from the cluster", node.Name, node.Status)
+		}
+	}
+
+	return diags, nil
+}
+
+// moduleList formats module names for a message
+func moduleList(modules []string) string {
+	if len(modules) == 0 {
+		return "none"
+	}
+	return strings.Join(modules, ", ")
+}
@@ -0,0 +1,71 @@
+// Package reflect builds Weaviate classes from Go types at runtime, for services that
+// ensure their schema on startup instead of running weave schema:
+//
+//	class, err := reflect.SchemaFor[Article](reflect.WithNaming(weave.NamingSnakeCase))
+//
+// It reads the json and weave tags the CLI reads. Comments aren't available at runtime,
+// so +weave markers don't apply: class settings are set on the returned class, classes
+// referenced by struct fields are named with WithClasses, and enums with WithEnums.
+package reflect
+
+import (
+	stdreflect "reflect"
+
+	"github.com/huffduff/weave"
+)
+
+// Option configures SchemaFor
+type Option func(*weave.ReflectOptions)
+
+// WithClassName names the class, instead of after the type
+func WithClassName(name string) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.ClassName = name
+	}
+}
+
+// WithNaming derives property names from fields without a JSON name with naming
+func WithNaming(naming weave.NamingStrategy) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.Naming = naming
+	}
+}
+
+// WithType maps a Go type, given by import path + "." + name, to a Weaviate data type
+func WithType(goType, dataType string) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.Types.Register(goType, dataType)
+	}
+}
+
+// WithClasses names the struct types that are classes of their own, so fields holding
+// them are references rather than nested objects
+func WithClasses(names ...string) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.Classes = append(opts.Classes, names...)
+	}
+}
+
+// WithEnums names the string types that are enums, stored as keywords: the CLI finds them
+// by their constants, which reflection can't list
+func WithEnums(names ...string) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.Enums = append(opts.Enums, names...)
+	}
+}
+
+// WithWarnings collects the warnings the CLI reports, such as unknown weave options, into diags
+func WithWarnings(diags *weave.Diagnostics) Option {
+	return func(opts *weave.ReflectOptions) {
+		opts.Warnings = diags
+	}
+}
+
+// SchemaFor builds the class of the struct type T
+func SchemaFor[T any](opts ...Option) (*weave.WeaviateClass, error) {
+	var options weave.ReflectOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return weave.ClassForType(stdreflect.TypeFor[T](), options)
+}
@@ -0,0 +1,359 @@
+package weave
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ReflectOptions configures ClassForType
+type ReflectOptions struct {
+	// ClassName names the class, by default after the type
+	ClassName string
+
+	// Naming derives property names from fields without a JSON name, camelCase by default
+	Naming NamingStrategy
+
+	// Types maps further Go types, by import path + "." + name, to Weaviate data types
+	Types TypeRegistry
+
+	// Classes names the struct types that are classes of their own, so fields holding
+	// them are references rather than nested objects
+	Classes []string
+
+	// Enums names the string types that are enums, stored as keywords like the enums the
+	// CLI finds by their constants
+	Enums []string
+
+	// Warnings, if set, collects the warnings the CLI reports for the same fields, such
+	// as unknown weave options
+	Warnings *Diagnostics
+}
+
+// ClassForType builds the class of a struct type at runtime, reading the same json and
+// weave tags as the generated schema. Comments and markers aren't available through
+// reflection: descriptions come from the description option only, and class settings
+// such as the vectorizer are left to the caller.
+func ClassForType(t reflect.Type, opts ReflectOptions) (*WeaviateClass, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("error reflecting %s: not a struct", t)
+	}
+	if err := opts.Naming.validate(); err != nil {
+		return nil, err
+	}
+
+	name := opts.ClassName
+	if name == "" {
+		name = t.Name()
+	}
+	if name == "" {
+		return nil, fmt.Errorf("error reflecting %s: an anonymous struct needs a class name", t)
+	}
+
+	r := reflector{opts: opts, nesting: map[reflect.Type]bool{t: true}}
+	properties, passthrough, err := r.properties(name, t)
+	if err != nil {
+		return nil, err
+	}
+	return &WeaviateClass{
+		Class:             name,
+		Properties:        properties,
+		Passthrough:       passthrough,
+		VectorIndexType:   "hnsw",
+		Vectorizer:        "text2vec-contextionary",
+		defaultVectorizer: true,
+	}, nil
+}
+
+// reflector maps struct fields to properties like processStruct does for source
+type reflector struct {
+	opts    ReflectOptions
+	nesting map[reflect.Type]bool // structs being reflected, which can't nest themselves
+}
+
+// properties returns the properties of the fields of a struct, and its passthrough maps
+func (r *reflector) properties(structName string, t reflect.Type) ([]WeaviateProperty, []WeaviateProperty, error) {
+	properties := []WeaviateProperty{}
+	var passthrough []WeaviateProperty
+	seen := make(map[string]WeaviateProperty)
+	var version string
+
+	fields, err := r.fields(structName, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, field := range fields {
+		fieldName := field.Name
+
+		jsonOpts := parseJSONTag(string(field.Tag))
+		if jsonOpts.Name == "-" {
+			return nil, nil, fmt.Errorf("field %s.%s is encoded as \"-\", which isn't a valid property name", structName, fieldName)
+		}
+		weaviateConfig, err := extractWeaviateConfig(string(field.Tag))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid weave tag on field %s.%s: %v", structName, fieldName, err)
+		}
+
+		enum, isSlice := r.enum(field.Type)
+		property, kind, err := convertField(propertyField{
+			structName: structName,
+			fieldName:  fieldName,
+			goType:     field.Type.String(),
+			json:       jsonOpts,
+			options:    weaviateConfig,
+			isMap:      underlying(field.Type).Kind() == reflect.Map,
+			direct:     underlying(field.Type) == field.Type,
+			enum:       enum,
+			enumSlice:  isSlice,
+			dataType: func() ([]string, error) {
+				return r.dataType(field.Type)
+			},
+		}, r.opts.Naming, &version, r.warnf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if kind == fieldSkipped {
+			continue
+		}
+
+		key := strings.ToLower(property.Name)
+		if prev, ok := seen[key]; ok {
+			return nil, nil, fmt.Errorf("field %s.%s maps to property %s, colliding with field %s", structName, fieldName, property.Name, prev.GoField)
+		}
+		if kind == fieldPassthrough {
+			seen[key] = property
+			passthrough = append(passthrough, property)
+			continue
+		}
+
+		if property.DataType[0] == "object" || property.DataType[0] == "object[]" {
+			if property.NestedProperties, err = r.nested(structName, fieldName, field.Type); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		seen[key] = property
+		properties = append(properties, property)
+	}
+
+	return properties, passthrough, nil
+}
+
+// fields lists the fields encoding/json marshals for a struct, like jsonFields does for
+// source, with the fields of embedded structs without a json name promoted
+func (r *reflector) fields(structName string, t reflect.Type) ([]reflect.StructField, error) {
+	var names []goName
+	var candidates []jsonCandidate
+	var fields []reflect.StructField
+	visited := make(map[reflect.Type]bool)
+
+	var walk func(t reflect.Type, depth int)
+	walk = func(t reflect.Type, depth int) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+			names = append(names, goName{name: field.Name, depth: depth})
+			tag := parseJSONTag(string(field.Tag))
+			if tag.Skip {
+				continue
+			}
+			if field.Anonymous {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if tag.Name == "" && embedded.Kind() == reflect.Struct {
+					walk(embedded, depth+1)
+					continue
+				}
+			}
+			if field.IsExported() {
+				candidates = append(candidates, newJSONCandidate(field.Name, tag, depth))
+				fields = append(fields, field)
+			}
+		}
+	}
+	walk(t, 0)
+
+	var err error
+	indexes := dominantFields(candidates, names, func(i int) {
+		r.warnf("several fields of %s are encoded as %q at the same depth of embedding, so encoding/json leaves them all out", structName, candidates[i].key)
+	}, func(i int) {
+		if err == nil {
+			name := candidates[i].name
+			err = fmt.Errorf("field %s promoted into %s is hidden by another field named %s, so generated code can't select it", name, structName, name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	promoted := make([]reflect.StructField, len(indexes))
+	for i, index := range indexes {
+		promoted[i] = fields[index]
+	}
+	return promoted, nil
+}
+
+// enum returns the string enum of ReflectOptions.Enums a field holds, if any, and whether
+// it holds a slice of it, like enumFieldType
+func (r *reflector) enum(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	isSlice := t.Kind() == reflect.Slice
+	if isSlice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String || !slices.Contains(r.opts.Enums, t.Name()) {
+		return "", false
+	}
+	return t.Name(), isSlice
+}
+
+// warnf reports a warning to ReflectOptions.Warnings, if set
+func (r *reflector) warnf(format string, args ...interface{}) {
+	if r.opts.Warnings != nil {
+		r.opts.Warnings.add(SeverityWarning, noPos, format, args...)
+	}
+}
+
+// dataType maps a Go type to Weaviate data types like determineWeaviateDataType
+func (r *reflector) dataType(t reflect.Type) ([]string, error) {
+	t = underlying(t)
+	if name := qualifiedTypeName(t); name != "" {
+		if dataType, ok := r.opts.Types.Lookup(name); ok {
+			return []string{dataType}, nil
+		}
+		if dataType, ok := builtinTypes.Lookup(name); ok {
+			return []string{dataType}, nil
+		}
+	}
+
+	// Helper types from weave types, recognized by name
+	if dataType, ok := helperTypes[t.Name()]; ok && t.Kind() == reflect.Struct {
+		return []string{dataType}, nil
+	}
+	if target, ok := refTarget(t); ok {
+		if target.Name() == "" {
+			return nil, fmt.Errorf("%s doesn't reference a class", t)
+		}
+		return []string{target.Name()}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return []string{"text"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{"int"}, nil
+	case reflect.Float32, reflect.Float64:
+		return []string{"number"}, nil
+	case reflect.Bool:
+		return []string{"boolean"}, nil
+	case reflect.Interface:
+		return []string{"text"}, nil
+	case reflect.Map:
+		return []string{"object"}, nil
+	case reflect.Struct:
+		if slices.Contains(r.opts.Classes, t.Name()) {
+			return []string{t.Name()}, nil
+		}
+		return []string{"object"}, nil
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes byte slices as base64, the encoding of blobs
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return []string{"blob"}, nil
+		}
+		elemType, err := r.dataType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		nativeTypes := []string{"text", "boolean", "int", "number", "date", "uuid", "object"}
+		if slices.Contains(nativeTypes, elemType[0]) {
+			return []string{elemType[0] + "[]"}, nil
+		}
+		if isReferenceType(elemType[0]) {
+			return elemType, nil
+		}
+		return []string{"object[]"}, nil
+	}
+	return nil, fmt.Errorf("unsupported type: %s", t)
+}
+
+// nested returns the nested properties of an object field, like nestedProperties
+func (r *reflector) nested(structName, fieldName string, t reflect.Type) ([]WeaviateProperty, error) {
+	t = underlying(t)
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = underlying(t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("field %s.%s holds %s, which has no properties; Weaviate requires nested properties for objects", structName, fieldName, t)
+	}
+	if r.nesting[t] {
+		return nil, fmt.Errorf("field %s.%s nests %s inside itself, which an object property can't hold", structName, fieldName, t.Name())
+	}
+	r.nesting[t] = true
+	defer delete(r.nesting, t)
+
+	properties, _, err := r.properties(t.Name(), t)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, fmt.Errorf("field %s.%s holds %s, which has no properties; Weaviate requires nested properties for objects", structName, fieldName, t.Name())
+	}
+	for _, prop := range properties {
+		if isReferenceType(prop.DataType[0]) {
+			return nil, fmt.Errorf("field %s.%s references class %s, but nested objects can't hold references", t.Name(), prop.GoField, prop.DataType[0])
+		}
+	}
+	return properties, nil
+}
+
+// underlying strips pointers and Optional[T] helper types off t
+func underlying(t reflect.Type) reflect.Type {
+	for {
+		switch {
+		case t.Kind() == reflect.Pointer:
+			t = t.Elem()
+		case t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Optional["):
+			value, ok := t.FieldByName("Value")
+			if !ok {
+				return t
+			}
+			t = value.Type
+		default:
+			return t
+		}
+	}
+}
+
+// refTarget returns the type a Ref[T] helper type references
+func refTarget(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !strings.HasPrefix(t.Name(), "Ref[") {
+		return nil, false
+	}
+	object, ok := t.FieldByName("Object")
+	if !ok || object.Type.Kind() != reflect.Pointer {
+		return nil, false
+	}
+	return object.Type.Elem(), true
+}
+
+// qualifiedTypeName returns the import path + "." + name of a named type, the key of
+// type registries, or "" for unnamed and predeclared types
+func qualifiedTypeName(t reflect.Type) string {
+	if t.PkgPath() == "" || t.Name() == "" {
+		return ""
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,112 @@
+package weave
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type reflectStatus string
+
+const (
+	reflectDraft     reflectStatus = "draft"
+	reflectPublished reflectStatus = "published"
+)
+
+type ReflectAudit struct {
+	CreatedBy string
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type reflectAddress struct {
+	StreetName string
+	ZipCode    string `json:"zip" weave:"tokenization=field"`
+}
+
+// The class both the CLI, reading this file, and ClassForType convert
+//
+// +weave
+type ReflectArticle struct {
+	ReflectAudit
+	UserID    string
+	Title     string            `json:"title" weave:"tokenization=word,description=Shown in lists"`
+	Views     int               `json:"views,omitempty" weave:"indexRangeFilters=true,indexFilterable=false"`
+	Score     *float64          `json:"score"`
+	Tags      []string          `json:"tags"`
+	Status    reflectStatus     `json:"status"`
+	Labels    []reflectStatus   `json:"labels"`
+	Thumbnail []byte            `json:"thumbnail"`
+	Address   reflectAddress    `json:"address"`
+	Addresses []reflectAddress  `json:"addresses"`
+	Meta      map[string]string `weave:"type=text"`
+	Version   int               `json:"version" weave:"version"`
+	Internal  string            `json:"-"`
+	hidden    string
+}
+
+// comparableProperties drops what only one side can know from properties: the position
+// and type expression of the Go field the CLI read
+func comparableProperties(props []WeaviateProperty) []WeaviateProperty {
+	out := make([]WeaviateProperty, len(props))
+	for i, prop := range props {
+		prop.Pos = WeaviateProperty{}.Pos
+		prop.GoType = ""
+		prop.NestedProperties = comparableProperties(prop.NestedProperties)
+		out[i] = prop
+	}
+	return out
+}
+
+// reflectSchema generates the schema of this file, as the CLI would for a package holding it
+func reflectSchema(t *testing.T, naming NamingStrategy) *WeaviateSchemaDefinition {
+	t.Helper()
+	src, err := os.ReadFile("reflect_class_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	schema, diags, err := GenerateWeaviateSchemaWithConfig(dir, &Config{Naming: naming})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	return schema
+}
+
+func TestClassForTypeMatchesSchema(t *testing.T) {
+	for _, naming := range []NamingStrategy{NamingCamelCase, NamingSnakeCase} {
+		t.Run(string(naming), func(t *testing.T) {
+			want, ok := reflectSchema(t, naming).class("ReflectArticle")
+			if !ok {
+				t.Fatal("no ReflectArticle class")
+			}
+
+			var warnings Diagnostics
+			got, err := ClassForType(reflect.TypeFor[ReflectArticle](), ReflectOptions{
+				Naming:   naming,
+				Enums:    []string{"reflectStatus"},
+				Warnings: &warnings,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+
+			if g, w := comparableProperties(got.Properties), comparableProperties(want.Properties); !reflect.DeepEqual(g, w) {
+				t.Errorf("properties differ:\nClassForType: %+v\nschema:       %+v", g, w)
+			}
+			if g, w := comparableProperties(got.Passthrough), comparableProperties(want.Passthrough); !reflect.DeepEqual(g, w) {
+				t.Errorf("passthrough properties differ:\nClassForType: %+v\nschema:       %+v", g, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package weave
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+)
+
+// ReindexOptions controls Reindex
+type ReindexOptions struct {
+	// ToClass receives the objects instead of the class they're read from. It must exist,
+	// e.g. applied with the new vectorizer config; references to the old class are kept.
+	ToClass string
+
+	// Vectors are the named vectors to recompute, e.g. one just added to the vector config;
+	// the others keep their values. Every vector is recomputed when it's empty.
+	Vectors []string
+
+	PageSize          int     // objects read per cursor request
+	BatchSize         int     // objects written per batch request
+	RequestsPerSecond float64 // upper bound on batch requests, 0 for unlimited
+
+	// Checkpoint is a file recording the progress after every batch, so running the same
+	// reindex again resumes it. It's removed once the reindex is done.
+	Checkpoint string
+
+	OnProgress func(ReindexProgress)
+}
+
+// ReindexProgress reports a reindex after every batch
+type ReindexProgress struct {
+	Tenant    string // "" without multi-tenancy
+	Reindexed int    // objects re-inserted, by resumed runs too
+}
+
+// reindexCheckpoint is the progress Reindex records in the checkpoint file
+type reindexCheckpoint struct {
+	Class     string            `json:"class"`
+	ToClass   string            `json:"toClass,omitempty"`
+	Vectors   []string          `json:"vectors,omitempty"`
+	After     map[string]string `json:"after"` // last object re-inserted per tenant
+	Done      []string          `json:"done"`  // tenants reindexed
+	Reindexed int               `json:"reindexed"`
+}
+
+// Reindex re-inserts the objects of a class without their vectors, so the vectorizer
+// computes them again after a change of model or vectorizer config. Objects keep their
+// IDs and properties; they're read with the objects cursor, tenant by tenant, and written
+// with the batch API. It returns the number of objects re-inserted, by resumed runs too.
+func Reindex(ctx context.Context, client *ClusterClient, className string, opts ReindexOptions) (int, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultDumpPageSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultRestoreBatch
+	}
+	target := className
+	if opts.ToClass != "" {
+		target = opts.ToClass
+	}
+
+	var class WeaviateClass
+	if err := client.do(ctx, http.MethodGet, "/schema/"+url.PathEscape(target), nil, nil, &class); err != nil {
+		return 0, fmt.Errorf("error reading class %s: %v", target, err)
+	}
+	if err := checkReindexVectors(class, opts.Vectors); err != nil {
+		return 0, err
+	}
+
+	cp, err := loadReindexCheckpoint(opts.Checkpoint, className, opts)
+	if err != nil {
+		return 0, err
+	}
+	tenants, err := copyTenants(ctx, client, className, target)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastBatch time.Time
+	for _, tenant := range tenants {
+		if slices.Contains(cp.Done, tenant) {
+			continue
+		}
+
+		err := objectPages(ctx, client, className, tenant, cp.After[tenant], opts.PageSize, func(objects []DumpObject) error {
+			for i := range objects {
+				obj := &objects[i]
+				obj.Class = target
+				if err := dropVectors(obj, opts.Vectors); err != nil {
+					return fmt.Errorf("error reading %s object %s: %v", className, obj.ID, err)
+				}
+			}
+
+			for batch := range slices.Chunk(objects, opts.BatchSize) {
+				if err := throttle(ctx, &lastBatch, opts.RequestsPerSecond); err != nil {
+					return err
+				}
+				if err := client.batchObjects(ctx, batch); err != nil {
+					return fmt.Errorf("error reindexing %s: %v", className, err)
+				}
+
+				cp.After[tenant] = batch[len(batch)-1].ID
+				cp.Reindexed += len(batch)
+				if err := cp.save(opts.Checkpoint); err != nil {
+					return err
+				}
+				if opts.OnProgress != nil {
+					opts.OnProgress(ReindexProgress{Tenant: tenant, Reindexed: cp.Reindexed})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return cp.Reindexed, err
+		}
+
+		cp.Done = append(cp.Done, tenant)
+		if err := cp.save(opts.Checkpoint); err != nil {
+			return cp.Reindexed, err
+		}
+	}
+
+	if opts.Checkpoint != "" {
+		if err := os.Remove(opts.Checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return cp.Reindexed, fmt.Errorf("error removing checkpoint: %v", err)
+		}
+	}
+	return cp.Reindexed, nil
+}
+
+// checkReindexVectors reports vectors a reindex couldn't recompute: named vectors the class
+// doesn't have or that have no vectorizer, or a class without any vectorizer
+func checkReindexVectors(class WeaviateClass, vectors []string) error {
+	for _, name := range vectors {
+		config, ok := class.VectorConfig[name]
+		if !ok {
+			return fmt.Errorf("class %s has no named vector %s (expected one of %v)", class.Class, name, slices.Sorted(maps.Keys(class.VectorConfig)))
+		}
+		if namedVectorizer(config) == "" {
+			return fmt.Errorf("named vector %s of %s has no vectorizer to recompute it with", name, class.Class)
+		}
+	}
+	if len(vectors) > 0 {
+		return nil
+	}
+
+	if class.Vectorizer != "" && class.Vectorizer != "none" {
+		return nil
+	}
+	for _, config := range class.VectorConfig {
+		if namedVectorizer(config) != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("class %s has no vectorizer to recompute its vectors with", class.Class)
+}
+
+// namedVectorizer returns the vectorizer module of a named vector's config, or "" for none
+func namedVectorizer(config interface{}) string {
+	vector, _ := config.(map[string]interface{})
+	vectorizer, _ := vector["vectorizer"].(map[string]interface{})
+	for module := range vectorizer {
+		if module != "none" {
+			return module
+		}
+	}
+	return ""
+}
+
+// dropVectors removes the named vectors of an object, or all of its vectors when names is empty
+func dropVectors(obj *DumpObject, names []string) error {
+	if len(names) == 0 {
+		obj.Vector = nil
+		obj.Vectors = nil
+		return nil
+	}
+	if len(obj.Vectors) == 0 {
+		return nil
+	}
+
+	var vectors map[string]json.RawMessage
+	if err := json.Unmarshal(obj.Vectors, &vectors); err != nil {
+		return err
+	}
+	for _, name := range names {
+		delete(vectors, name)
+	}
+	var err error
+	obj.Vectors, err = json.Marshal(vectors)
+	return err
+}
+
+// throttle waits until a request may be sent at no more than perSecond requests per
+// second, given when the last one was sent
+func throttle(ctx context.Context, last *time.Time, perSecond float64) error {
+	if perSecond <= 0 {
+		return nil
+	}
+	wait := time.Until(last.Add(time.Duration(float64(time.Second) / perSecond)))
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	*last = time.Now()
+	return nil
+}
+
+// loadReindexCheckpoint reads the checkpoint of an interrupted reindex, which must be of
+// the same reindex, or starts a new one when there's none
+func loadReindexCheckpoint(path, className string, opts ReindexOptions) (*reindexCheckpoint, error) {
+	cp := &reindexCheckpoint{Class: className, ToClass: opts.ToClass, Vectors: opts.Vectors, After: make(map[string]string)}
+	if path == "" {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+
+	var saved reindexCheckpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error reading checkpoint %s: %v", path, err)
+	}
+	if saved.Class != cp.Class || saved.ToClass != cp.ToClass || !slices.Equal(saved.Vectors, cp.Vectors) {
+		return nil, fmt.Errorf("checkpoint %s is of another reindex, of %s; remove it to start over", path, saved.Class)
+	}
+	if saved.After == nil {
+		saved.After = make(map[string]string)
+	}
+	return &saved, nil
+}
+
+// save writes the checkpoint, replacing the previous one at once
+func (cp *reindexCheckpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
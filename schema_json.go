@@ -0,0 +1,362 @@
+package weave
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// goSchema is the x-weave-go block of the schema JSON: what the generated code needs beyond
+// the Weaviate schema, so code can be generated from a schema file instead of the sources
+type goSchema struct {
+	SourceHash string             `json:"sourceHash,omitempty"`
+	Classes    map[string]goClass `json:"classes"`
+	Enums      []goEnum           `json:"enums,omitempty"`
+	Backfills  []goBackfill       `json:"backfills,omitempty"`
+}
+
+// goClass holds the Go side of a class; File is the source file of the type, whose
+// directory the generated code goes to without --output
+type goClass struct {
+	Package           string                `json:"package"`
+	File              string                `json:"file,omitempty"`
+	Line              int                   `json:"line,omitempty"`
+	ReadConsistency   string                `json:"readConsistency,omitempty"`
+	WriteConsistency  string                `json:"writeConsistency,omitempty"`
+	ReadOnly          bool                  `json:"readOnly,omitempty"`
+	RenamedFrom       string                `json:"renamedFrom,omitempty"`
+	DefaultVectorizer bool                  `json:"defaultVectorizer,omitempty"`
+	Properties        map[string]goProperty `json:"properties,omitempty"`
+	Passthrough       []goPassthrough       `json:"passthrough,omitempty"`
+}
+
+// goProperty holds the Go side of a property and of its nested properties
+type goProperty struct {
+	Field   string                `json:"field"`
+	Type    string                `json:"type"`
+	Enum    string                `json:"enum,omitempty"`
+	Key     string                `json:"key,omitempty"`
+	JSON    bool                  `json:"json,omitempty"`
+	Version bool                  `json:"version,omitempty"`
+	Nested  map[string]goProperty `json:"nested,omitempty"`
+}
+
+// goPassthrough is a map field passed through with type=object, which isn't in the schema
+type goPassthrough struct {
+	Name     string   `json:"name"`
+	DataType []string `json:"dataType"`
+	goProperty
+}
+
+// goEnum is an Enum with the directory of its package
+type goEnum struct {
+	Enum
+	Dir string `json:"dir"`
+}
+
+// goBackfill is a Backfill with the source file of its function
+type goBackfill struct {
+	Name  string `json:"name"`
+	Func  string `json:"func"`
+	Class string `json:"class"`
+	File  string `json:"file"`
+}
+
+// ToCodegenJSON is ToJSON with the x-weave-go block, holding the Go side of the classes
+// that SchemaFromJSON needs to generate code from the schema file
+func (s *WeaviateSchemaDefinition) ToCodegenJSON(pretty bool) ([]byte, error) {
+	data, err := s.ToJSON(false)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	block, err := json.Marshal(s.goSchema())
+	if err != nil {
+		return nil, err
+	}
+	doc["x-weave-go"] = block
+	if pretty {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return json.Marshal(doc)
+}
+
+// goSchema collects the Go side of the schema
+func (s *WeaviateSchemaDefinition) goSchema() *goSchema {
+	out := &goSchema{SourceHash: s.SourceHash, Classes: make(map[string]goClass, len(s.Classes))}
+	for _, class := range s.Classes {
+		c := goClass{
+			Package:           class.Package,
+			File:              filepath.ToSlash(class.Pos.Filename),
+			Line:              class.Pos.Line,
+			ReadConsistency:   class.ReadConsistency,
+			WriteConsistency:  class.WriteConsistency,
+			ReadOnly:          class.ReadOnly,
+			RenamedFrom:       class.RenamedFrom,
+			DefaultVectorizer: class.defaultVectorizer,
+			Properties:        goProperties(class.Properties),
+		}
+		for _, prop := range class.Passthrough {
+			c.Passthrough = append(c.Passthrough, goPassthrough{Name: prop.Name, DataType: prop.DataType, goProperty: newGoProperty(prop)})
+		}
+		out.Classes[class.Class] = c
+	}
+	for _, enum := range s.Enums {
+		out.Enums = append(out.Enums, goEnum{Enum: enum, Dir: filepath.ToSlash(enum.Dir)})
+	}
+	for _, backfill := range s.Backfills {
+		out.Backfills = append(out.Backfills, goBackfill{Name: backfill.Name, Func: backfill.Func, Class: backfill.Class, File: filepath.ToSlash(backfill.Pos.Filename)})
+	}
+	return out
+}
+
+// goProperties maps the properties generated from Go fields to their Go side
+func goProperties(props []WeaviateProperty) map[string]goProperty {
+	var out map[string]goProperty
+	for _, prop := range props {
+		if prop.GoField == "" {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]goProperty, len(props))
+		}
+		out[prop.Name] = newGoProperty(prop)
+	}
+	return out
+}
+
+// newGoProperty returns the Go side of a property
+func newGoProperty(prop WeaviateProperty) goProperty {
+	return goProperty{
+		Field:   prop.GoField,
+		Type:    prop.GoType,
+		Enum:    prop.Enum,
+		Key:     prop.JSONKey,
+		JSON:    prop.JSON,
+		Version: prop.Version,
+		Nested:  goProperties(prop.NestedProperties),
+	}
+}
+
+// ParseSchema reads schema JSON, either a {"classes": [...]} schema as written by weave, an
+// array of classes as exported from a cluster, or a single class. The classes are checked
+// against Weaviate's class object spec, reporting unknown keys and values of the wrong type,
+// and normalized with the defaults Weaviate fills in: capitalized class names, the hnsw
+// vector index, word tokenization for text, and the default vectorizer for classes without
+// one. The x-weave blocks weave writes are read back into the classes. The error reports
+// JSON that can't be decoded; callers decide what to do with the diagnostics.
+func ParseSchema(r io.Reader) (*WeaviateSchemaDefinition, Diagnostics, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading schema: %v", err)
+	}
+	diags, err := ValidateSchemaJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing schema JSON: %v", err)
+	}
+	schema := &WeaviateSchemaDefinition{Classes: []WeaviateClass{}}
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		if _, ok := d["classes"]; !ok {
+			var class WeaviateClass
+			if err := json.Unmarshal(data, &class); err != nil {
+				return nil, diags, fmt.Errorf("error parsing class JSON: %v", err)
+			}
+			schema.Classes = append(schema.Classes, class)
+			break
+		}
+		for _, key := range slices.Sorted(maps.Keys(d)) {
+			if key != "classes" && !strings.HasPrefix(key, "x-") {
+				diags.add(SeverityWarning, token.Position{}, "unknown key %s in the schema document is ignored", key)
+			}
+		}
+		if err := json.Unmarshal(data, schema); err != nil {
+			return nil, diags, fmt.Errorf("error parsing schema JSON: %v", err)
+		}
+	case []interface{}:
+		if err := json.Unmarshal(data, &schema.Classes); err != nil {
+			return nil, diags, fmt.Errorf("error parsing schema JSON: %v", err)
+		}
+	default:
+		return nil, diags, fmt.Errorf("expected a schema, an array of classes or a class")
+	}
+
+	for i := range schema.Classes {
+		normalizeClass(&schema.Classes[i], &diags)
+	}
+	if err := schema.restoreBlocks(data); err != nil {
+		return nil, diags, err
+	}
+	return schema, diags, nil
+}
+
+// normalizeClass fills in the settings of a parsed class that Weaviate defaults
+func normalizeClass(class *WeaviateClass, diags *Diagnostics) {
+	// A missing name is reported by the spec
+	if class.Class != "" {
+		class.Class = capitalize(class.Class)
+	}
+	if class.Properties == nil {
+		class.Properties = []WeaviateProperty{}
+	}
+	if class.VectorIndexType == "" && len(class.VectorConfig) == 0 {
+		class.VectorIndexType = "hnsw"
+	}
+	if class.Vectorizer == "" && len(class.VectorConfig) == 0 {
+		class.Vectorizer = "text2vec-contextionary"
+		class.defaultVectorizer = true
+	}
+	normalizeProperties(class.Class, class.Properties, diags)
+}
+
+// normalizeProperties fills in the settings of parsed properties that Weaviate defaults,
+// recursing into nested properties
+func normalizeProperties(path string, props []WeaviateProperty, diags *Diagnostics) {
+	for i := range props {
+		prop := &props[i]
+		for j, dataType := range prop.DataType {
+			switch dataType {
+			case "string", "string[]":
+				text := strings.Replace(dataType, "string", "text", 1)
+				diags.add(SeverityWarning, token.Position{}, "property %s.%s has the deprecated data type %s, read as %s", path, prop.Name, dataType, text)
+				prop.DataType[j] = text
+			default:
+				if !slices.Contains(nativeDataTypes, strings.TrimSuffix(dataType, "[]")) {
+					prop.DataType[j] = capitalize(dataType)
+				}
+			}
+		}
+		if isTextType(prop.DataType) && prop.Tokenization == "" {
+			prop.Tokenization = "word"
+		}
+		normalizeProperties(path+"."+prop.Name, prop.NestedProperties, diags)
+	}
+}
+
+// nativeDataTypes are Weaviate's data types other than references, without their [] suffix
+var nativeDataTypes = []string{"text", "int", "number", "boolean", "date", "uuid", "blob", "object", "geoCoordinates", "phoneNumber"}
+
+// restoreBlocks reads the x-weave blocks of a schema document back into its classes: the
+// owners, deprecated and renamed properties, and the Go side when it has an x-weave-go block
+func (s *WeaviateSchemaDefinition) restoreBlocks(data []byte) error {
+	var doc struct {
+		Go         *goSchema                    `json:"x-weave-go"`
+		Owners     map[string]string            `json:"x-weave-owners"`
+		Deprecated map[string]map[string]string `json:"x-weave-deprecated"`
+		Renamed    map[string]map[string]string `json:"x-weave-renamed"`
+	}
+	// Arrays of classes and single classes have no blocks
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+
+	for i := range s.Classes {
+		class := &s.Classes[i]
+		class.Owner = doc.Owners[class.Class]
+		for j := range class.Properties {
+			prop := &class.Properties[j]
+			if note, ok := doc.Deprecated[class.Class][prop.Name]; ok {
+				prop.Deprecated = true
+				prop.DeprecationNote = note
+			}
+			prop.RenamedFrom = doc.Renamed[class.Class][prop.Name]
+		}
+	}
+	// The blocks are filled in again by ToJSON
+	s.Owners, s.Deprecated, s.Renamed = nil, nil, nil
+
+	if doc.Go == nil {
+		return nil
+	}
+	s.SourceHash = doc.Go.SourceHash
+	for i := range s.Classes {
+		class := &s.Classes[i]
+		c, ok := doc.Go.Classes[class.Class]
+		if !ok {
+			return fmt.Errorf("class %s isn't in the x-weave-go block", class.Class)
+		}
+		class.Package = c.Package
+		class.Pos = token.Position{Filename: filepath.FromSlash(c.File), Line: c.Line}
+		class.ReadConsistency = c.ReadConsistency
+		class.WriteConsistency = c.WriteConsistency
+		class.ReadOnly = c.ReadOnly
+		class.RenamedFrom = c.RenamedFrom
+		class.defaultVectorizer = c.DefaultVectorizer
+		restoreGoProperties(class.Properties, c.Properties)
+		for _, p := range c.Passthrough {
+			prop := WeaviateProperty{Name: p.Name, DataType: p.DataType}
+			p.goProperty.restore(&prop)
+			class.Passthrough = append(class.Passthrough, prop)
+		}
+	}
+	for _, enum := range doc.Go.Enums {
+		enum.Enum.Dir = filepath.FromSlash(enum.Dir)
+		s.Enums = append(s.Enums, enum.Enum)
+	}
+	for _, backfill := range doc.Go.Backfills {
+		s.Backfills = append(s.Backfills, Backfill{
+			Name:  backfill.Name,
+			Func:  backfill.Func,
+			Class: backfill.Class,
+			Pos:   token.Position{Filename: filepath.FromSlash(backfill.File)},
+		})
+	}
+	return nil
+}
+
+// SchemaFromJSON reads a schema as ToCodegenJSON writes it with ParseSchema, with the Go side
+// of every class taken from its x-weave-go block, so code can be generated from a reviewed
+// schema file instead of the sources. Schemas without the block, e.g. read from a cluster,
+// and schemas ParseSchema reports errors for are an error.
+func SchemaFromJSON(data []byte) (*WeaviateSchemaDefinition, error) {
+	schema, diags, err := ParseSchema(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := diags.Err(); err != nil {
+		return nil, fmt.Errorf("invalid schema:\n%v", err)
+	}
+
+	var doc struct {
+		Go *goSchema `json:"x-weave-go"`
+	}
+	if json.Unmarshal(data, &doc) != nil || doc.Go == nil {
+		return nil, fmt.Errorf("schema has no x-weave-go block; write it with weave schema --codegen")
+	}
+	return schema, nil
+}
+
+// restoreGoProperties sets the Go side of the properties, recursing into nested properties
+func restoreGoProperties(props []WeaviateProperty, goProps map[string]goProperty) {
+	for i := range props {
+		prop := &props[i]
+		if p, ok := goProps[prop.Name]; ok {
+			p.restore(prop)
+			restoreGoProperties(prop.NestedProperties, p.Nested)
+		}
+	}
+}
+
+// restore sets the Go side of a property; its position isn't kept
+func (p goProperty) restore(prop *WeaviateProperty) {
+	prop.GoField = p.Field
+	prop.GoType = p.Type
+	prop.Enum = p.Enum
+	prop.JSONKey = p.Key
+	prop.JSON = p.JSON
+	prop.Version = p.Version
+}
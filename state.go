@@ -0,0 +1,290 @@
+package weave
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultStateClass is the class the weaviate state backend stores the schema in
+const DefaultStateClass = "WeaveState"
+
+// stateObjectID is the ID of the object holding the last-applied schema
+const stateObjectID = "6c6d1a6e-7765-4176-9561-737461746500"
+
+// StateBackend stores the schema last applied to a cluster, so later diffs and applies
+// can run where the previous schema artifact isn't on disk
+type StateBackend interface {
+	// Load returns the last-applied schema JSON, or nil when none was saved
+	Load(ctx context.Context) ([]byte, error)
+	// Save replaces the last-applied schema JSON
+	Save(ctx context.Context, schema []byte) error
+}
+
+// OpenState returns the state backend at location:
+//
+//	file://path/to/state.json or a plain path    a local file
+//	s3://bucket/key                              an S3 object, with credentials from the AWS_* environment variables
+//	weaviate://host[:port][/Class]               an object in a Weaviate class, WeaveState by default;
+//	                                             weaviates:// uses https, WEAVIATE_API_KEY authenticates
+func OpenState(location string) (StateBackend, error) {
+	scheme, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return FileState{Path: location}, nil
+	}
+
+	switch scheme {
+	case "file":
+		return FileState{Path: rest}, nil
+	case "s3":
+		bucket, key, _ := strings.Cut(rest, "/")
+		if bucket == "" || key == "" {
+			return nil, fmt.Errorf("invalid S3 state location %q (expected s3://bucket/key)", location)
+		}
+		return &S3State{Bucket: bucket, Key: key}, nil
+	case "weaviate", "weaviates":
+		host, class, _ := strings.Cut(rest, "/")
+		if host == "" {
+			return nil, fmt.Errorf("invalid Weaviate state location %q (expected %s://host/Class)", location, scheme)
+		}
+		httpScheme := "http"
+		if scheme == "weaviates" {
+			httpScheme = "https"
+		}
+		return &WeaviateState{
+			Client: NewClusterClient(host, httpScheme, os.Getenv("WEAVIATE_API_KEY")),
+			Class:  class,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown state backend %q (expected file, s3, weaviate or weaviates)", scheme)
+}
+
+// FileState stores the schema in a local file
+type FileState struct {
+	Path string
+}
+
+func (s FileState) Load(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %v", err)
+	}
+	return data, nil
+}
+
+func (s FileState) Save(ctx context.Context, schema []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return fmt.Errorf("error creating state directory: %v", err)
+	}
+	if err := os.WriteFile(s.Path, schema, 0644); err != nil {
+		return fmt.Errorf("error writing state: %v", err)
+	}
+	return nil
+}
+
+// WeaviateState stores the schema as an object of a class in Weaviate itself, which is
+// created on the first save
+type WeaviateState struct {
+	Client *ClusterClient
+	Class  string // DefaultStateClass when empty
+}
+
+func (s *WeaviateState) class() string {
+	if s.Class == "" {
+		return DefaultStateClass
+	}
+	return s.Class
+}
+
+func (s *WeaviateState) Load(ctx context.Context) ([]byte, error) {
+	var obj struct {
+		Properties struct {
+			Schema string `json:"schema"`
+		} `json:"properties"`
+	}
+	err := s.Client.do(ctx, http.MethodGet, "/objects/"+url.PathEscape(s.class())+"/"+stateObjectID, nil, nil, &obj)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %v", err)
+	}
+	return []byte(obj.Properties.Schema), nil
+}
+
+func (s *WeaviateState) Save(ctx context.Context, schema []byte) error {
+	classes, err := s.Client.schemaProperties(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := classes[s.class()]; !ok {
+		err := s.Client.createClass(ctx, WeaviateClass{
+			Class:       s.class(),
+			Description: "Schema last applied by weave",
+			Vectorizer:  "none",
+			Properties: []WeaviateProperty{
+				{Name: "schema", DataType: []string{"text"}},
+				{Name: "appliedAt", DataType: []string{"date"}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	obj := map[string]interface{}{
+		"class": s.class(),
+		"id":    stateObjectID,
+		"properties": map[string]interface{}{
+			"schema":    string(schema),
+			"appliedAt": time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	// PUT replaces the object and fails when it doesn't exist yet
+	err = s.Client.do(ctx, http.MethodPut, "/objects/"+url.PathEscape(s.class())+"/"+stateObjectID, nil, obj, nil)
+	if isNotFound(err) {
+		err = s.Client.do(ctx, http.MethodPost, "/objects", nil, obj, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing state: %v", err)
+	}
+	return nil
+}
+
+// S3State stores the schema as an S3 object. Requests are signed with the credentials
+// in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, for the region in
+// AWS_REGION (us-east-1 by default). AWS_ENDPOINT_URL_S3 points at S3-compatible
+// storage, addressed by path.
+type S3State struct {
+	Bucket string
+	Key    string
+}
+
+func (s *S3State) Load(ctx context.Context) ([]byte, error) {
+	data, status, err := s.do(ctx, http.MethodGet, nil)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %v", err)
+	}
+	return data, nil
+}
+
+func (s *S3State) Save(ctx context.Context, schema []byte) error {
+	if _, _, err := s.do(ctx, http.MethodPut, schema); err != nil {
+		return fmt.Errorf("error writing state: %v", err)
+	}
+	return nil
+}
+
+// do sends a signed request for the object and returns the response body and status
+func (s *S3State) do(ctx context.Context, method string, body []byte) ([]byte, int, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	u := "https://" + s.Bucket + ".s3." + region + ".amazonaws.com/" + s3Escape(s.Key)
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+		u = strings.TrimSuffix(endpoint, "/") + "/" + s3Escape(s.Bucket) + "/" + s3Escape(s.Key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating request: %v", err)
+	}
+	if err := signS3(req, body, region, time.Now()); err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error calling S3: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("error reading S3 response: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, fmt.Errorf("%s s3://%s/%s: status %d: %s", method, s.Bucket, s.Key, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, resp.StatusCode, nil
+}
+
+// signS3 adds an AWS Signature Version 4 Authorization header to req
+func signS3(req *http.Request, body []byte, region string, now time.Time) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for S3 state")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headers := "host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n"
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+		signed = append(signed, "x-amz-security-token")
+		headers += "x-amz-security-token:" + token + "\n"
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{req.Method, req.URL.EscapedPath(), "", headers, signedHeaders, payloadHash}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// s3Escape escapes an object key for the URL path, keeping its slashes. Signing needs
+// everything but unreserved characters escaped.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
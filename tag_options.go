@@ -0,0 +1,176 @@
+package weave
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Weave tag options are comma-separated key=value pairs. A value holding commas is
+// written in single quotes, with '' for a quote inside it, or escaped with backslashes;
+// JSON objects and arrays are read whole:
+//
+//	weave:"description=The article body, tokenization=word"
+//	weave:"description='Title, as shown', tokenization=field"
+//	weave:"description=Rock\\, paper and scissors"
+//	weave:"moduleConfig={\"text2vec-openai\":{\"skip\":true}}"
+
+// extractWeaviatePropMarkers extracts the options of +weave:prop: markers in a field's
+// comments, written like weave tag options:
+//
+//	// +weave:prop:tokenization=field
+//	// +weave:prop:indexFilterable=false,indexSearchable=true
+func extractWeaviatePropMarkers(groups ...*ast.CommentGroup) (map[string]string, error) {
+	var config map[string]string
+	for _, cg := range groups {
+		if cg == nil {
+			continue
+		}
+		for _, c := range cg.List {
+			_, options, ok := strings.Cut(c.Text, weaviatePropMarker)
+			if !ok {
+				continue
+			}
+			if config == nil {
+				config = make(map[string]string)
+			}
+			if err := parseWeaviateOptions(strings.TrimSpace(options), config); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return config, nil
+}
+
+// extractWeaviateConfig extracts Weaviate-specific configurations from the struct tag
+func extractWeaviateConfig(tagValue string) (map[string]string, error) {
+	config := make(map[string]string)
+	tags := reflect.StructTag(tagValue)
+
+	tag := tags.Get(weaviateTag)
+	if tag == "" {
+		return config, nil
+	}
+
+	if err := parseWeaviateOptions(tag, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// parseWeaviateOptions adds the options of a weave tag to config
+func parseWeaviateOptions(tag string, config map[string]string) error {
+	for _, part := range splitTagOptions(tag) {
+		key, value, hasValue, err := parseTagOption(part)
+		switch {
+		case err != nil:
+			return err
+		case hasValue:
+			config[key] = value
+		case key != "":
+			// Flags like json
+			config[key] = "true"
+		}
+	}
+	return nil
+}
+
+// splitTagOptions splits a weave tag at the commas outside quoted values, JSON values
+// and escapes, so options like
+// moduleConfig={"text2vec-openai":{"skip":true,"vectorizePropertyName":false}} stay whole.
+// Quotes and brackets only start a quoted or JSON value where the value starts, so the
+// ones inside text, like description=5" screen, stay literal.
+func splitTagOptions(tag string) []string {
+	var parts []string
+	depth, quote, start := 0, byte(0), 0
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case quote == '\'':
+			if c == '\'' {
+				if i+1 < len(tag) && tag[i+1] == '\'' {
+					i++ // '' is a quote inside the value
+				} else {
+					quote = 0
+				}
+			}
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case c == '\'' && depth == 0 && valueStarts(tag[start:i]):
+			quote = c
+		case c == '"' && (depth > 0 || valueStarts(tag[start:i])):
+			quote = c
+		case (c == '{' || c == '[') && (depth > 0 || valueStarts(tag[start:i])):
+			depth++
+		case (c == '}' || c == ']') && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// valueStarts reports whether the value of an option starts after option, its text so far:
+// the key and the first =, followed by spaces at most
+func valueStarts(option string) bool {
+	option = strings.TrimRight(option, " ")
+	return option != "" && strings.IndexByte(option, '=') == len(option)-1
+}
+
+// parseTagOption splits an option into its key and value, removing the quotes and
+// escapes of the value. Spaces around the key and value are dropped, so options can be
+// separated by ", " and values hold several words.
+func parseTagOption(option string) (key, value string, hasValue bool, err error) {
+	key, raw, hasValue := strings.Cut(option, "=")
+	key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+	if !hasValue {
+		return key, "", false, nil
+	}
+
+	switch {
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Count(raw[1:len(raw)-1], "'")%2 != 0 {
+			return "", "", false, fmt.Errorf("unterminated quote in option %s", key)
+		}
+		return key, strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), true, nil
+	case strings.HasPrefix(raw, `"`):
+		unquoted, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", "", false, fmt.Errorf("invalid quoted value in option %s: %v", key, err)
+		}
+		return key, unquoted, true, nil
+	case strings.HasPrefix(raw, "{") || strings.HasPrefix(raw, "["):
+		return key, raw, true, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+		}
+		b.WriteByte(raw[i])
+	}
+	return key, b.String(), true, nil
+}
+
+// formatTagOption writes an option so parseTagOption reads back key and value, quoting
+// values that hold commas, quotes or backslashes, or start or end with spaces it would trim
+func formatTagOption(key, value string) string {
+	if (strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")) && json.Valid([]byte(value)) {
+		return key + "=" + value
+	}
+	if strings.ContainsAny(value, `,'"\`) || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") || strings.TrimSpace(value) != value {
+		return key + "='" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return key + "=" + value
+}
@@ -0,0 +1,136 @@
+package weave
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tagOptionValues are option values that need quoting or escaping to survive a weave tag
+var tagOptionValues = []string{
+	"",
+	"word",
+	"several words",
+	"The article body, as written",
+	"a=b",
+	"a=b,c=d",
+	"it's",
+	"''",
+	"'quoted'",
+	`say "hi"`,
+	`"`,
+	`back\slash`,
+	`trailing\`,
+	`\,`,
+	" padded ",
+	"{not json",
+	"[not json",
+	`{"text2vec-openai":{"skip":true,"vectorizePropertyName":false}}`,
+	`["a","b,c"]`,
+	`{"quote":"it's"}`,
+}
+
+func TestFormatTagOptionRoundTrip(t *testing.T) {
+	for _, value := range tagOptionValues {
+		option := formatTagOption("key", value)
+
+		parts := splitTagOptions(option)
+		if len(parts) != 1 {
+			t.Errorf("formatTagOption(%q) = %s, split into %q", value, option, parts)
+			continue
+		}
+		key, parsed, hasValue, err := parseTagOption(parts[0])
+		if err != nil || key != "key" || !hasValue || parsed != value {
+			t.Errorf("formatTagOption(%q) = %s, parsed as %q=%q (value %v, err %v)", value, option, key, parsed, hasValue, err)
+		}
+	}
+}
+
+func TestParseWeaviateOptionsRoundTrip(t *testing.T) {
+	// Every value in one tag, so each has to end where the next option starts
+	want := make(map[string]string)
+	var options []string
+	for i, value := range tagOptionValues {
+		key := "option" + strings.Repeat("x", i)
+		want[key] = value
+		options = append(options, formatTagOption(key, value))
+	}
+
+	for _, sep := range []string{",", ", "} {
+		got := make(map[string]string)
+		if err := parseWeaviateOptions(strings.Join(options, sep), got); err != nil {
+			t.Fatalf("parseWeaviateOptions(%q): %v", strings.Join(options, sep), err)
+		}
+		if !maps.Equal(got, want) {
+			for key, value := range want {
+				if got[key] != value {
+					t.Errorf("options joined with %q: %s = %q, want %q", sep, key, got[key], value)
+				}
+			}
+		}
+	}
+}
+
+func TestParseTagOption(t *testing.T) {
+	tests := []struct {
+		option   string
+		key      string
+		value    string
+		hasValue bool
+		err      bool
+	}{
+		{option: "json", key: "json"},
+		{option: " tokenization = word ", key: "tokenization", value: "word", hasValue: true},
+		{option: "description=", key: "description", hasValue: true},
+		{option: "description='a, b'", key: "description", value: "a, b", hasValue: true},
+		{option: "description='it''s'", key: "description", value: "it's", hasValue: true},
+		{option: `description="a, \"b\""`, key: "description", value: `a, "b"`, hasValue: true},
+		{option: `description=a\,b`, key: "description", value: "a,b", hasValue: true},
+		{option: "description=x=y", key: "description", value: "x=y", hasValue: true},
+		{option: `description=5" screen`, key: "description", value: `5" screen`, hasValue: true},
+		{option: "description=a {b", key: "description", value: "a {b", hasValue: true},
+		{option: "description='unterminated", err: true},
+		{option: `description="unterminated`, err: true},
+	}
+
+	for _, tt := range tests {
+		key, value, hasValue, err := parseTagOption(tt.option)
+		if (err != nil) != tt.err {
+			t.Errorf("parseTagOption(%s) error = %v, want error %v", tt.option, err, tt.err)
+			continue
+		}
+		if !tt.err && (key != tt.key || value != tt.value || hasValue != tt.hasValue) {
+			t.Errorf("parseTagOption(%s) = %q, %q, %v, want %q, %q, %v", tt.option, key, value, hasValue, tt.key, tt.value, tt.hasValue)
+		}
+	}
+}
+
+func TestSplitTagOptions(t *testing.T) {
+	tests := []struct {
+		tag   string
+		parts []string
+	}{
+		{tag: "json", parts: []string{"json"}},
+		{tag: "type=text, tokenization=word", parts: []string{"type=text", " tokenization=word"}},
+		{tag: `description=5" screen, tokenization=word`, parts: []string{`description=5" screen`, " tokenization=word"}},
+		{tag: "description=size {small, tokenization=word", parts: []string{"description=size {small", " tokenization=word"}},
+		{tag: "description=a [b, tokenization=word", parts: []string{"description=a [b", " tokenization=word"}},
+		{tag: "description=a] b, tokenization=word", parts: []string{"description=a] b", " tokenization=word"}},
+		{tag: "description=x={y, tokenization=word", parts: []string{"description=x={y", " tokenization=word"}},
+		{tag: "description=it's, tokenization=word", parts: []string{"description=it's", " tokenization=word"}},
+		{tag: "type=text[],tokenization=word", parts: []string{"type=text[]", "tokenization=word"}},
+		{tag: `description="a, b", tokenization=word`, parts: []string{`description="a, b"`, " tokenization=word"}},
+		{tag: `description = "a, b",tokenization=word`, parts: []string{`description = "a, b"`, "tokenization=word"}},
+		{tag: "description='a, b', tokenization=word", parts: []string{"description='a, b'", " tokenization=word"}},
+		{tag: `description=a\, b, tokenization=word`, parts: []string{`description=a\, b`, " tokenization=word"}},
+		{tag: `moduleConfig={"m":{"s":"a, {b"}}, tokenization=word`, parts: []string{`moduleConfig={"m":{"s":"a, {b"}}`, " tokenization=word"}},
+		{tag: `moduleConfig= ["a", "b,c"],type=text[]`, parts: []string{`moduleConfig= ["a", "b,c"]`, "type=text[]"}},
+	}
+
+	for _, tt := range tests {
+		if parts := splitTagOptions(tt.tag); !slices.Equal(parts, tt.parts) {
+			t.Errorf("splitTagOptions(%s) = %q, want %q", tt.tag, parts, tt.parts)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package weave
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+)
+
+// structDecl is a struct type declared in the scanned package, with the scope of its file
+type structDecl struct {
+	spec  *ast.TypeSpec
+	scope *fileScope
+}
+
+// collectStructs indexes the struct types declared across the package
+func collectStructs(files []*ast.File, scopes []*fileScope) map[string]*structDecl {
+	structs := make(map[string]*structDecl)
+	for i, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if _, ok := typeSpec.Type.(*ast.StructType); ok {
+					structs[typeSpec.Name.Name] = &structDecl{spec: typeSpec, scope: scopes[i]}
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// resolveClassStruct returns the struct a marked type declaration stands for, and the
+// scope its field types resolve in.
+//
+// Besides plain structs, it supports named instantiations of generic structs
+// (`type ArticlePage Page[Article]` or `type ArticlePage = Page[Article]`) and
+// aliases or defined types of structs declared in the package. A generic struct
+// itself has no concrete fields and is rejected.
+func resolveClassStruct(scope *fileScope, typeSpec *ast.TypeSpec) (*ast.StructType, *fileScope, error) {
+	var target *ast.Ident
+	var args []ast.Expr
+
+	switch t := typeSpec.Type.(type) {
+	case *ast.StructType:
+		if typeSpec.TypeParams != nil {
+			return nil, nil, fmt.Errorf("generic struct %s can't be a class; mark a named instantiation instead", typeSpec.Name.Name)
+		}
+		return t, scope, nil
+	case *ast.Ident:
+		target = t
+	case *ast.IndexExpr:
+		target, _ = t.X.(*ast.Ident)
+		args = []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		target, _ = t.X.(*ast.Ident)
+		args = t.Indices
+	}
+
+	if target == nil {
+		return nil, nil, fmt.Errorf("unsupported type %s; only structs declared in this package and their instantiations can be classes", types.ExprString(typeSpec.Type))
+	}
+	if typeSpec.TypeParams != nil {
+		return nil, nil, fmt.Errorf("generic type %s can't be a class; mark a named instantiation instead", typeSpec.Name.Name)
+	}
+
+	decl, ok := scope.structs[target.Name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a struct declared in this package", target.Name)
+	}
+
+	params := typeParamNames(decl.spec.TypeParams)
+	if len(params) != len(args) {
+		return nil, nil, fmt.Errorf("%s has %d type parameters, got %d type arguments", target.Name, len(params), len(args))
+	}
+
+	structType := decl.spec.Type.(*ast.StructType)
+	if len(params) == 0 {
+		return structType, decl.scope, nil
+	}
+
+	substitutions := make(map[string]ast.Expr, len(params))
+	for i, name := range params {
+		substitutions[name] = args[i]
+	}
+
+	return instantiateStruct(structType, substitutions), decl.scope.withImports(scope), nil
+}
+
+// typeParamNames lists the names of a type parameter list in order
+func typeParamNames(list *ast.FieldList) []string {
+	if list == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// instantiateStruct copies a generic struct with its type parameters substituted
+func instantiateStruct(structType *ast.StructType, substitutions map[string]ast.Expr) *ast.StructType {
+	fields := &ast.FieldList{Opening: structType.Fields.Opening, Closing: structType.Fields.Closing}
+	for _, field := range structType.Fields.List {
+		instance := *field
+		instance.Type = substituteTypeParams(field.Type, substitutions)
+		fields.List = append(fields.List, &instance)
+	}
+	return &ast.StructType{Struct: structType.Struct, Fields: fields}
+}
+
+// substituteTypeParams replaces type parameter identifiers within a type expression
+func substituteTypeParams(expr ast.Expr, substitutions map[string]ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if sub, ok := substitutions[t.Name]; ok {
+			return sub
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{Star: t.Star, X: substituteTypeParams(t.X, substitutions)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Lbrack: t.Lbrack, Len: t.Len, Elt: substituteTypeParams(t.Elt, substitutions)}
+	case *ast.MapType:
+		return &ast.MapType{Map: t.Map, Key: substituteTypeParams(t.Key, substitutions), Value: substituteTypeParams(t.Value, substitutions)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: t.X, Lbrack: t.Lbrack, Index: substituteTypeParams(t.Index, substitutions), Rbrack: t.Rbrack}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(t.Indices))
+		for i, index := range t.Indices {
+			indices[i] = substituteTypeParams(index, substitutions)
+		}
+		return &ast.IndexListExpr{X: t.X, Lbrack: t.Lbrack, Indices: indices, Rbrack: t.Rbrack}
+	}
+	return expr
+}
+
+// withImports returns a copy of the scope that also knows the imports of other,
+// for type arguments written in a different file than the generic struct.
+// The scope's own imports win when both files use the same name.
+func (s *fileScope) withImports(other *fileScope) *fileScope {
+	scope := *s
+	scope.imports = maps.Clone(other.imports)
+	maps.Copy(scope.imports, s.imports)
+	return &scope
+}
@@ -0,0 +1,30 @@
+package weave
+
+// TypeRegistry maps Go types to Weaviate data types.
+//
+// Keys are fully qualified as import path + "." + type name
+// (e.g. "github.com/shopspring/decimal.Decimal"), or a bare type name for
+// types declared in the scanned package itself.
+type TypeRegistry map[string]string
+
+// Register maps a Go type to a Weaviate data type, replacing any previous mapping
+func (r *TypeRegistry) Register(goType, dataType string) {
+	if *r == nil {
+		*r = make(TypeRegistry)
+	}
+	(*r)[goType] = dataType
+}
+
+// Lookup returns the Weaviate data type registered for a Go type
+func (r TypeRegistry) Lookup(goType string) (string, bool) {
+	dataType, ok := r[goType]
+	return dataType, ok
+}
+
+// builtinTypes are consulted after the user registry
+var builtinTypes = TypeRegistry{
+	"time.Time":                   "date",
+	"time.Duration":               "int",
+	"github.com/google/uuid.UUID": "uuid",
+	"github.com/gofrs/uuid.UUID":  "uuid",
+}
@@ -0,0 +1,189 @@
+package weave
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// classSpec is the Weaviate class object spec, as a JSON Schema
+//
+//go:embed spec/class.schema.json
+var classSpec []byte
+
+// specNode is the subset of JSON Schema used by the bundled spec
+type specNode struct {
+	Ref                  string               `json:"$ref"`
+	Type                 string               `json:"type"`
+	Required             []string             `json:"required"`
+	Properties           map[string]*specNode `json:"properties"`
+	AdditionalProperties json.RawMessage      `json:"additionalProperties"`
+	Items                *specNode            `json:"items"`
+	Enum                 []string             `json:"enum"`
+	Pattern              string               `json:"pattern"`
+	Definitions          map[string]*specNode `json:"definitions"`
+}
+
+// specValidator checks decoded JSON against a spec, collecting every violation
+type specValidator struct {
+	root  *specNode
+	diags Diagnostics
+}
+
+// ValidateSchemaJSON checks a schema against Weaviate's class object spec, catching
+// unknown config keys and values of the wrong type before the schema reaches a
+// cluster. data holds either a {"classes": [...]} schema as written by weave, an
+// array of classes or a single class. The error reports JSON that can't be decoded.
+func ValidateSchemaJSON(data []byte) (Diagnostics, error) {
+	var root specNode
+	if err := json.Unmarshal(classSpec, &root); err != nil {
+		return nil, fmt.Errorf("error parsing bundled class spec: %v", err)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing schema JSON: %v", err)
+	}
+
+	v := &specValidator{root: &root}
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		if classes, ok := d["classes"]; ok {
+			v.validateClasses(classes, "classes")
+		} else {
+			v.validate(&root, d, "class")
+		}
+	case []interface{}:
+		v.validateClasses(d, "")
+	default:
+		v.errorf("", "expected a schema, an array of classes or a class")
+	}
+
+	return v.diags, nil
+}
+
+// validateClasses checks every element of a class array
+func (v *specValidator) validateClasses(value interface{}, path string) {
+	classes, ok := value.([]interface{})
+	if !ok {
+		v.errorf(path, "expected array, got %s", jsonType(value))
+		return
+	}
+	for i, class := range classes {
+		v.validate(v.root, class, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+// validate checks a value against a spec node and, recursively, its children
+func (v *specValidator) validate(node *specNode, value interface{}, path string) {
+	if node.Ref != "" {
+		name := strings.TrimPrefix(node.Ref, "#/definitions/")
+		node = v.root.Definitions[name]
+	}
+
+	if node.Type != "" && !hasJSONType(value, node.Type) {
+		v.errorf(path, "expected %s, got %s", node.Type, jsonType(value))
+		return
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, key := range node.Required {
+			if _, ok := val[key]; !ok {
+				v.errorf(path, "missing required key %s", key)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(val)) {
+			keyPath := joinPath(path, key)
+			if child, ok := node.Properties[key]; ok {
+				v.validate(child, val[key], keyPath)
+				continue
+			}
+			v.validateAdditional(node, key, val[key], keyPath)
+		}
+	case []interface{}:
+		if node.Items != nil {
+			for i, item := range val {
+				v.validate(node.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case string:
+		if len(node.Enum) > 0 && !slices.Contains(node.Enum, val) {
+			v.errorf(path, "invalid value %q (expected one of %s)", val, strings.Join(node.Enum, ", "))
+		}
+		if node.Pattern != "" && !regexp.MustCompile(node.Pattern).MatchString(val) {
+			v.errorf(path, "invalid value %q (must match %s)", val, node.Pattern)
+		}
+	}
+}
+
+// validateAdditional checks a key the spec doesn't list, which additionalProperties
+// either forbids or describes with a spec of its own
+func (v *specValidator) validateAdditional(node *specNode, key string, value interface{}, path string) {
+	additional := strings.TrimSpace(string(node.AdditionalProperties))
+	switch {
+	case additional == "false":
+		known := slices.Sorted(maps.Keys(node.Properties))
+		v.errorf(path, "unknown key %s (expected one of %s)", key, strings.Join(known, ", "))
+	case strings.HasPrefix(additional, "{"):
+		var child specNode
+		if err := json.Unmarshal(node.AdditionalProperties, &child); err == nil {
+			v.validate(&child, value, path)
+		}
+	}
+}
+
+// errorf records a violation at a JSON path like classes[0].vectorIndexConfig.ef
+func (v *specValidator) errorf(path, format string, args ...interface{}) {
+	if path != "" {
+		format = "%s: " + format
+		args = append([]interface{}{path}, args...)
+	}
+	v.diags.add(SeverityError, token.Position{}, format, args...)
+}
+
+// hasJSONType reports whether a decoded JSON value has the JSON Schema type
+func hasJSONType(value interface{}, typ string) bool {
+	switch typ {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return jsonType(value) == typ
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// joinPath appends an object key to a JSON path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
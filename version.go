@@ -0,0 +1,26 @@
+package weave
+
+import "runtime/debug"
+
+// modulePath is the import path of this module
+const modulePath = "github.com/huffduff/weave"
+
+// Version returns the version of weave compiled into the running binary,
+// or "(devel)" when it can't be determined
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return "(devel)"
+}
@@ -0,0 +1,104 @@
+package weave
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WeaviateVersion is a Weaviate release to generate the schema for; patch releases don't matter
+type WeaviateVersion struct {
+	Major, Minor int
+}
+
+// ParseWeaviateVersion parses versions like "1.25", "1.25.3" or "v1.25"
+func ParseWeaviateVersion(s string) (WeaviateVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return WeaviateVersion{}, fmt.Errorf("invalid Weaviate version %q (expected major.minor, e.g. 1.25)", s)
+	}
+
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return WeaviateVersion{}, fmt.Errorf("invalid Weaviate version %q (expected major.minor, e.g. 1.25)", s)
+		}
+		nums[i] = n
+	}
+
+	return WeaviateVersion{Major: nums[0], Minor: nums[1]}, nil
+}
+
+// String returns the version as major.minor
+func (v WeaviateVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Before reports whether v is an earlier release than other
+func (v WeaviateVersion) Before(other WeaviateVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+// Releases introducing the schema features weave can emit
+var (
+	vectorIndexTypeSince = map[string]WeaviateVersion{
+		"flat":    {1, 23},
+		"dynamic": {1, 25},
+	}
+	tokenizationSince = map[string]WeaviateVersion{
+		"trigram":   {1, 24},
+		"gse":       {1, 24},
+		"kagome_kr": {1, 25},
+	}
+	multiTenancySince = map[string]WeaviateVersion{
+		"enabled":              {1, 20},
+		"autoTenantCreation":   {1, 25},
+		"autoTenantActivation": {1, 25},
+	}
+	namedVectorsSince      = WeaviateVersion{1, 24}
+	indexRangeFiltersSince = WeaviateVersion{1, 26}
+)
+
+// checkTargetVersion reports the features of the schema the target Weaviate release doesn't support
+func checkTargetVersion(schema *WeaviateSchemaDefinition, target WeaviateVersion, diags *Diagnostics) {
+	checkVersionFeatures(schema, target, "targeting "+target.String(), diags)
+}
+
+// checkVersionFeatures reports the features of the schema a Weaviate release doesn't
+// support, explaining where the release comes from with source
+func checkVersionFeatures(schema *WeaviateSchemaDefinition, target WeaviateVersion, source string, diags *Diagnostics) {
+	for _, class := range schema.Classes {
+		requires := func(feature string, since WeaviateVersion) {
+			if target.Before(since) {
+				diags.add(SeverityError, class.Pos, "class %s uses %s, which needs Weaviate %s (%s)", class.Class, feature, since, source)
+			}
+		}
+
+		if since, ok := vectorIndexTypeSince[class.VectorIndexType]; ok {
+			requires("vectorIndexType "+class.VectorIndexType, since)
+		}
+		if len(class.VectorConfig) > 0 {
+			requires("named vectors (vectorConfig)", namedVectorsSince)
+		}
+		for _, option := range slices.Sorted(maps.Keys(multiTenancySince)) {
+			if _, ok := class.MultiTenancyConfig[option]; ok {
+				requires("multiTenancyConfig."+option, multiTenancySince[option])
+			}
+		}
+
+		for _, prop := range class.Properties {
+			if since, ok := tokenizationSince[prop.Tokenization]; ok && target.Before(since) {
+				diags.add(SeverityError, prop.Pos, "property %s.%s uses tokenization %s, which needs Weaviate %s (%s)", class.Class, prop.Name, prop.Tokenization, since, source)
+			}
+			if prop.IndexRangeFilters && target.Before(indexRangeFiltersSince) {
+				diags.add(SeverityError, prop.Pos, "property %s.%s uses indexRangeFilters, which needs Weaviate %s (%s)", class.Class, prop.Name, indexRangeFiltersSince, source)
+			}
+		}
+	}
+}